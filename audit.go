@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// ==========================
+// 🔹 Log audit transaksi
+// ==========================
+//
+// Setiap perubahan transaksi (tambah, edit, hapus, pulihkan) dicatat di
+// transaction_audit di dalam DB transaction yang sama dengan perubahannya,
+// lengkap dengan pelaku, isi sebelum/sesudah dan pengaruhnya ke saldo room.
+
+const (
+	auditCreate  = "create"
+	auditUpdate  = "update"
+	auditDelete  = "delete"
+	auditRestore = "restore"
+)
+
+// recordAudit menambahkan satu entri audit. before/after disalin, jadi
+// boleh diubah lagi oleh pemanggil setelahnya.
+func recordAudit(ctx context.Context, tx Store, actorID int, action string, before, after *Transaction, saldoDelta Money) error {
+	e := AuditEntry{UserID: actorID, Action: action, SaldoDelta: saldoDelta}
+	for _, src := range []struct {
+		t   *Transaction
+		dst **Transaction
+	}{{before, &e.Before}, {after, &e.After}} {
+		if src.t == nil {
+			continue
+		}
+		snapshot := *src.t
+		*src.dst = &snapshot
+		e.TransactionID, e.RoomID = snapshot.ID, snapshot.RoomID
+	}
+
+	if err := tx.Audit().Append(ctx, &e); err != nil {
+		return fmt.Errorf("Gagal mencatat log audit: %w", err)
+	}
+	return nil
+}
+
+// changedFields mengembalikan nama field (sesuai JSON) yang berbeda antara
+// before dan after.
+func changedFields(before, after *Transaction) []string {
+	if before == nil || after == nil {
+		return []string{}
+	}
+	changed := []string{}
+	if before.Jenis != after.Jenis {
+		changed = append(changed, "jenis")
+	}
+	if before.Kategori != after.Kategori {
+		changed = append(changed, "kategori")
+	}
+	if before.Nominal != after.Nominal {
+		changed = append(changed, "nominal")
+	}
+	if before.Keterangan != after.Keterangan {
+		changed = append(changed, "keterangan")
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		changed = append(changed, "tags")
+	}
+	if !before.OccurredAt.Equal(after.OccurredAt) {
+		changed = append(changed, "occurred_at")
+	}
+	if (before.DeletedAt == nil) != (after.DeletedAt == nil) {
+		changed = append(changed, "deleted_at")
+	}
+	return changed
+}
+
+type auditView struct {
+	AuditEntry
+	UserName      string   `json:"user_name"`
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// auditViews melengkapi entri audit dengan nama pelaku dan daftar field
+// yang berubah.
+func (s *Server) auditViews(ctx context.Context, roomID int, entries []AuditEntry) ([]auditView, error) {
+	members, err := s.store.Users().ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	names := map[int]string{}
+	for _, u := range members {
+		names[u.ID] = u.FullName
+	}
+
+	views := make([]auditView, 0, len(entries))
+	for _, e := range entries {
+		views = append(views, auditView{
+			AuditEntry:    e,
+			UserName:      names[e.UserID],
+			ChangedFields: changedFields(e.Before, e.After),
+		})
+	}
+	return views, nil
+}
+
+// ==========================
+// 🔹 API: Riwayat perubahan satu transaksi
+// ==========================
+//
+// GET /transaksi/riwayat?id=N. Tetap bisa dibuka walaupun transaksi sudah
+// dihapus, selama transaksinya milik room pemanggil.
+func (s *Server) GetRiwayatTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "id tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	entries, err := s.store.Audit().ListByTransaction(ctx, id)
+	if err != nil {
+		http.Error(w, "Gagal mengambil riwayat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(entries) == 0 || entries[0].RoomID != caller.RoomID {
+		writeError(w, http.StatusNotFound, "not_found", "Riwayat transaksi tidak ditemukan")
+		return
+	}
+
+	views, err := s.auditViews(ctx, caller.RoomID, entries)
+	if err != nil {
+		http.Error(w, "Gagal mengambil riwayat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "success",
+		"transaction_id": id,
+		"total_data":     len(views),
+		"riwayat":        views,
+	})
+}
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+// ==========================
+// 🔹 API: Log aktivitas room
+// ==========================
+//
+// GET /rooms/aktivitas?limit=50&before_id=N, terbaru dulu. next_before_id
+// dipakai untuk mengambil halaman berikutnya.
+func (s *Server) GetAktivitasRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := defaultActivityLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxActivityLimit {
+			http.Error(w, fmt.Sprintf("limit harus antara 1 dan %d", maxActivityLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var beforeID int64
+	if v := q.Get("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, "before_id tidak valid", http.StatusBadRequest)
+			return
+		}
+		beforeID = n
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	entries, err := s.store.Audit().ListByRoom(ctx, caller.RoomID, beforeID, limit)
+	if err != nil {
+		http.Error(w, "Gagal mengambil aktivitas: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views, err := s.auditViews(ctx, caller.RoomID, entries)
+	if err != nil {
+		http.Error(w, "Gagal mengambil aktivitas: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var next any
+	if len(entries) == limit {
+		next = entries[len(entries)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "success",
+		"room_id":        caller.RoomID,
+		"total_data":     len(views),
+		"aktivitas":      views,
+		"next_before_id": next,
+	})
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Token sesi (access + refresh)
+// ==========================
+//
+// Access token berupa JWT HS256 berumur pendek yang dikirim lewat header
+// "Authorization: Bearer <token>". Refresh token berupa string acak yang
+// hanya disimpan hash-nya di tabel refresh_tokens dan diputar (rotate)
+// setiap kali dipakai.
+
+// Secret HMAC untuk access token, diisi dari config lewat applyConfig.
+var tokenSecret []byte
+
+var (
+	errTokenInvalid = errors.New("token tidak valid")
+	errTokenExpired = errors.New("token sudah kedaluwarsa")
+)
+
+type accessClaims struct {
+	Subject   string `json:"sub"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func signAccessToken(userID int, now time.Time) (string, error) {
+	claims := accessClaims{
+		Subject:   strconv.Itoa(userID),
+		Type:      "access",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(cfg.AccessTokenTTL.Duration).Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + tokenSignature(unsigned), nil
+}
+
+func parseAccessToken(token string, now time.Time) (int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return 0, errTokenInvalid
+	}
+
+	expected := tokenSignature(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return 0, errTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, errTokenInvalid
+	}
+
+	var claims accessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Type != "access" {
+		return 0, errTokenInvalid
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return 0, errTokenExpired
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return 0, errTokenInvalid
+	}
+	return userID, nil
+}
+
+func tokenSignature(unsigned string) string {
+	mac := hmac.New(sha256.New, tokenSecret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens membuat access token baru dan menyimpan refresh token baru
+// untuk user tsb.
+func issueTokens(ctx context.Context, st Store, userID int) (map[string]interface{}, error) {
+	now := time.Now()
+
+	accessToken, err := signAccessToken(userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat access token: %w", err)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("gagal membuat refresh token: %w", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	err = st.Tokens().Create(ctx, RefreshToken{
+		UserID:    userID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(cfg.RefreshTokenTTL.Duration),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan refresh token: %w", err)
+	}
+
+	return map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// ==========================
+// 🔹 Middleware: wajib login
+// ==========================
+
+type authUser struct {
+	ID       int
+	RoomID   int
+	Currency string
+}
+
+type ctxKey int
+
+const authUserKey ctxKey = iota
+
+func currentUser(ctx context.Context) authUser {
+	u, _ := ctx.Value(authUserKey).(authUser)
+	return u
+}
+
+// requireAuth memastikan request membawa access token yang valid lalu
+// menaruh identitas pemanggil (user + room) ke dalam context. Handler di
+// belakangnya wajib memakai currentUser(r.Context()) dan tidak boleh
+// mempercayai user_id/room_id dari body atau query string.
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="couple-wallet"`)
+			http.Error(w, "Token akses wajib dikirim", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := parseAccessToken(token, time.Now())
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.store.Users().Get(r.Context(), userID)
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "User tidak ditemukan", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Gagal memeriksa user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		caller := authUser{ID: user.ID, RoomID: user.RoomID, Currency: defaultCurrency}
+		if caller.RoomID != 0 {
+			room, err := s.store.Rooms().Get(r.Context(), caller.RoomID)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				http.Error(w, "Gagal memeriksa room: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if room.Currency != "" {
+				caller.Currency = room.Currency
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), authUserKey, caller)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// ==========================
+// 🔹 Handler: Refresh Token
+// ==========================
+func (s *Server) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "refresh_token wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	var tokens map[string]interface{}
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Refresh token hanya boleh dipakai sekali (rotate)
+		userID, err := tx.Tokens().Consume(ctx, hashRefreshToken(req.RefreshToken), time.Now())
+		if errors.Is(err, ErrNotFound) {
+			return clientError(http.StatusUnauthorized, "", "Refresh token tidak valid")
+		}
+		if err != nil {
+			return fmt.Errorf("Gagal memeriksa refresh token: %w", err)
+		}
+
+		tokens, err = issueTokens(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// ==========================
+// 🔹 Handler: Logout (cabut refresh token)
+// ==========================
+func (s *Server) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "refresh_token wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	err := s.store.Tokens().Revoke(r.Context(), hashRefreshToken(req.RefreshToken), caller.ID, time.Now())
+	if err != nil {
+		http.Error(w, "Gagal logout: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Logout berhasil",
+	})
+}
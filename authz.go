@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ==========================
+// 🔹 Otorisasi room & transaksi
+// ==========================
+//
+// Semua aturan akses didaftarkan di tabel routing main.go lewat
+// requireRoom / requireTransaction, sehingga handler baru otomatis ikut
+// diperiksa tanpa perlu menulis ulang pengecekan di dalam handler.
+
+// writeError mengirim error terstruktur dalam bentuk JSON.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeErrorData(w, status, code, message, nil)
+}
+
+// writeErrorData sama dengan writeError, ditambah field lain di body
+// (mis. data terkini saat terjadi konflik versi).
+func writeErrorData(w http.ResponseWriter, status int, code, message string, data map[string]interface{}) {
+	body := map[string]interface{}{
+		"status":  "error",
+		"code":    code,
+		"message": message,
+	}
+	for k, v := range data {
+		body[k] = v
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// httpError membawa status dan pesan untuk client dari dalam WithTx.
+// Mengembalikannya dari fn membuat transaksi di-rollback, lalu handler
+// menulis respon lewat writeTxError. Code kosong = respon teks biasa.
+type httpError struct {
+	Status  int
+	Code    string
+	Message string
+	Data    map[string]interface{} // field tambahan di body JSON (opsional)
+}
+
+func (e *httpError) Error() string { return e.Message }
+
+func clientError(status int, code, message string) error {
+	return &httpError{Status: status, Code: code, Message: message}
+}
+
+// writeTxError menulis error hasil WithTx: httpError apa adanya, error
+// lain sebagai 500.
+func writeTxError(w http.ResponseWriter, err error) {
+	var he *httpError
+	switch {
+	case errors.As(err, &he) && he.Code != "":
+		writeErrorData(w, he.Status, he.Code, he.Message, he.Data)
+	case he != nil:
+		http.Error(w, he.Message, he.Status)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+const maxPeekBody = 1 << 20
+
+// peekJSONBody membaca body JSON tanpa "menghabiskan" r.Body sehingga
+// handler berikutnya tetap bisa men-decode body yang sama. Upload
+// multipart tidak dibaca sama sekali, dan body yang lebih besar dari
+// maxPeekBody hanya dibaca sebagian: sisanya tetap bisa dibaca handler.
+func peekJSONBody(r *http.Request, v interface{}) error {
+	if r.Body == nil || isMultipart(r) {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	if err != nil {
+		return err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && strings.HasPrefix(mediaType, "multipart/")
+}
+
+// requireRoom memastikan pemanggil sudah login, sudah tergabung di sebuah
+// room, dan tidak mencoba mengakses room lain lewat room_id di query
+// string maupun body.
+func (s *Server) requireRoom(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		caller := currentUser(r.Context())
+		if caller.RoomID == 0 {
+			writeError(w, http.StatusForbidden, "no_room", "Kamu belum tergabung di room manapun")
+			return
+		}
+
+		if q := r.URL.Query().Get("room_id"); q != "" {
+			roomID, err := strconv.Atoi(q)
+			if err != nil || roomID != caller.RoomID {
+				writeError(w, http.StatusForbidden, "room_forbidden", "Kamu bukan anggota room ini")
+				return
+			}
+		}
+
+		var body struct {
+			RoomID *int `json:"room_id"`
+		}
+		if err := peekJSONBody(r, &body); err == nil && body.RoomID != nil && *body.RoomID != caller.RoomID {
+			writeError(w, http.StatusForbidden, "room_forbidden", "Kamu bukan anggota room ini")
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// transactionOwners mengambil room_id dan user_id sebuah transaksi untuk
+// setiap ruang id yang boleh dipakai requireTransaction. "user_transactions"
+// adalah id lama endpoint /pemasukan dan /pengeluaran (legacy_user_id);
+// "other_transaction" memakai id yang sama dengan tabel transactions.
+var transactionOwners = map[string]func(ctx context.Context, st Store, id int) (roomID, userID int, err error){
+	"transactions": func(ctx context.Context, st Store, id int) (int, int, error) {
+		t, err := st.Transactions().Get(ctx, id)
+		return t.RoomID, t.UserID, err
+	},
+	"user_transactions": func(ctx context.Context, st Store, id int) (int, int, error) {
+		t, err := st.Transactions().GetByLegacyUserID(ctx, id)
+		return t.RoomID, t.UserID, err
+	},
+	"other_transaction": func(ctx context.Context, st Store, id int) (int, int, error) {
+		t, err := st.Transactions().Get(ctx, id)
+		return t.RoomID, t.UserID, err
+	},
+	// transaksi yang sedang di tempat sampah
+	"trash": func(ctx context.Context, st Store, id int) (int, int, error) {
+		t, err := st.Transactions().GetDeleted(ctx, id)
+		return t.RoomID, t.UserID, err
+	},
+}
+
+// requireTransaction memastikan transaksi yang dituju (field "id" di body
+// atau query string) berada di room pemanggil. Jika keduanya diisi, id-nya
+// harus sama. Jika ownerOnly bernilai true, transaksi juga harus milik
+// pemanggil sendiri.
+func (s *Server) requireTransaction(table string, ownerOnly bool, next http.HandlerFunc) http.HandlerFunc {
+	return s.requireRoom(s.transactionAccess(table, ownerOnly, next))
+}
+
+// transactionAccess adalah pemeriksaan requireTransaction tanpa
+// requireRoom, untuk handler yang sudah berada di balik requireRoom.
+func (s *Server) transactionAccess(table string, ownerOnly bool, next http.HandlerFunc) http.HandlerFunc {
+	owners, ok := transactionOwners[table]
+	if !ok {
+		panic(fmt.Sprintf("requireTransaction: tabel %q tidak dikenal", table))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller := currentUser(r.Context())
+
+		var body struct {
+			ID int `json:"id"`
+		}
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil || id == 0 {
+			if err := peekJSONBody(r, &body); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_json", "JSON tidak valid")
+				return
+			}
+			id = body.ID
+		} else if peekJSONBody(r, &body) == nil && body.ID != 0 && body.ID != id {
+			// Handler membaca id dari body, jadi id di body harus sama
+			// dengan id di query yang diperiksa di sini.
+			writeError(w, http.StatusBadRequest, "id_mismatch", "id di query dan body berbeda")
+			return
+		}
+		if id == 0 {
+			writeError(w, http.StatusBadRequest, "missing_id", "id wajib diisi")
+			return
+		}
+
+		roomID, ownerID, err := owners(r.Context(), s.store, id)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Transaksi tidak ditemukan")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal", "Gagal memeriksa transaksi: "+err.Error())
+			return
+		}
+
+		if roomID != caller.RoomID {
+			writeError(w, http.StatusForbidden, "room_forbidden", "Transaksi ini bukan milik room kamu")
+			return
+		}
+		if ownerOnly && ownerID != caller.ID {
+			writeError(w, http.StatusForbidden, "not_owner", "Hanya pemilik transaksi yang boleh mengubahnya")
+			return
+		}
+
+		next(w, r)
+	}
+}
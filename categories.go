@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ==========================
+// 🔹 Kategori transaksi per room
+// ==========================
+//
+// Setiap room punya daftar kategori sendiri (tabel categories), diisi
+// defaultCategories saat room dibuat. Kategori bisa punya satu tingkat
+// sub-kategori, ikon dan warna, dan tidak pernah dihapus: DELETE hanya
+// mengarsipkan, jadi transaksi lama tetap menunjuk ke kategori yang sama.
+// Transaksi menyimpan category_id dan salinan nama kategori; rename ikut
+// memperbarui salinan tsb.
+
+// defaultCategories adalah kategori bawaan room baru (harus sama dengan
+// seed di migrations/0012_categories.up.sql).
+var defaultCategories = []Category{
+	{Name: "Makanan", Icon: "🍔", Color: "#F97316"},
+	{Name: "Belanja", Icon: "🛒", Color: "#3B82F6"},
+	{Name: "Hiburan", Icon: "🎬", Color: "#A855F7"},
+	{Name: "Tagihan", Icon: "🧾", Color: "#EF4444"},
+	{Name: "Lainnya", Icon: "📦", Color: "#6B7280"},
+}
+
+const (
+	maxCategoryNameLen = 50
+	maxCategoryIconLen = 32
+)
+
+var categoryColorRe = regexp.MustCompile(`^#[0-9A-F]{6}$`)
+
+// seedDefaultCategories membuat kategori bawaan untuk room baru.
+func seedDefaultCategories(ctx context.Context, tx Store, roomID int) error {
+	for _, def := range defaultCategories {
+		c := def
+		c.RoomID = roomID
+		if err := tx.Categories().Create(ctx, &c); err != nil {
+			return fmt.Errorf("Gagal membuat kategori bawaan: %w", err)
+		}
+	}
+	return nil
+}
+
+// categoryActive bernilai true jika kategori (dan induknya) belum diarsipkan.
+func categoryActive(ctx context.Context, st Store, c Category) (bool, error) {
+	if c.ArchivedAt != nil {
+		return false, nil
+	}
+	if c.ParentID == 0 {
+		return true, nil
+	}
+	parent, err := st.Categories().Get(ctx, c.ParentID)
+	if err != nil {
+		return false, err
+	}
+	return parent.ArchivedAt == nil, nil
+}
+
+// resolveKategori mencari kategori aktif di room untuk transaksi, dari
+// category_id atau (jika id 0) dari nama kategori.
+func resolveKategori(ctx context.Context, st Store, roomID, id int, name string) (Category, error) {
+	var (
+		c   Category
+		err error
+	)
+	name = strings.TrimSpace(name)
+	switch {
+	case id != 0:
+		c, err = st.Categories().Get(ctx, id)
+		if err == nil && c.RoomID != roomID {
+			err = ErrNotFound
+		}
+	case name != "":
+		c, err = st.Categories().FindByName(ctx, roomID, name)
+	default:
+		return c, clientError(http.StatusBadRequest, "kategori_required", "kategori wajib diisi")
+	}
+	if errors.Is(err, ErrNotFound) {
+		return c, clientError(http.StatusBadRequest, "invalid_kategori", "Kategori tidak ditemukan di room ini")
+	}
+	if err != nil {
+		return c, fmt.Errorf("Gagal membaca kategori: %w", err)
+	}
+
+	active, err := categoryActive(ctx, st, c)
+	if err != nil {
+		return c, fmt.Errorf("Gagal membaca kategori: %w", err)
+	}
+	if !active {
+		return c, clientError(http.StatusBadRequest, "kategori_archived", "Kategori "+c.Name+" sudah diarsipkan")
+	}
+	return c, nil
+}
+
+// validateCategoryFields menormalkan lalu memeriksa name, icon dan color.
+func validateCategoryFields(c *Category) string {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Icon = strings.TrimSpace(c.Icon)
+	c.Color = strings.ToUpper(strings.TrimSpace(c.Color))
+
+	switch {
+	case c.Name == "":
+		return "name wajib diisi"
+	case utf8.RuneCountInString(c.Name) > maxCategoryNameLen:
+		return fmt.Sprintf("name maksimal %d karakter", maxCategoryNameLen)
+	case utf8.RuneCountInString(c.Icon) > maxCategoryIconLen:
+		return fmt.Sprintf("icon maksimal %d karakter", maxCategoryIconLen)
+	case c.Color != "" && !categoryColorRe.MatchString(c.Color):
+		return "color harus berformat #RRGGBB"
+	}
+	return ""
+}
+
+// checkCategoryName menolak nama yang sudah dipakai kategori lain di room.
+func checkCategoryName(ctx context.Context, tx Store, c Category) error {
+	other, err := tx.Categories().FindByName(ctx, c.RoomID, c.Name)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Gagal membaca kategori: %w", err)
+	}
+	if other.ID != c.ID {
+		return clientError(http.StatusConflict, "kategori_exists", "Kategori "+other.Name+" sudah ada di room ini")
+	}
+	return nil
+}
+
+// checkCategoryParent memastikan induk ada di room yang sama dan merupakan
+// kategori utama, dan kategori yang dipindah ke bawah induk tidak punya
+// sub-kategori sendiri.
+func checkCategoryParent(ctx context.Context, tx Store, c Category) error {
+	if c.ParentID == 0 {
+		return nil
+	}
+	if c.ParentID == c.ID {
+		return clientError(http.StatusBadRequest, "invalid_parent", "Kategori tidak bisa menjadi induk dirinya sendiri")
+	}
+	parent, err := tx.Categories().Get(ctx, c.ParentID)
+	if errors.Is(err, ErrNotFound) || (err == nil && parent.RoomID != c.RoomID) {
+		return clientError(http.StatusBadRequest, "invalid_parent", "Kategori induk tidak ditemukan di room ini")
+	}
+	if err != nil {
+		return fmt.Errorf("Gagal membaca kategori: %w", err)
+	}
+	if parent.ParentID != 0 {
+		return clientError(http.StatusBadRequest, "invalid_parent", "Sub-kategori hanya boleh satu tingkat")
+	}
+
+	if c.ID != 0 {
+		all, err := tx.Categories().List(ctx, c.RoomID, true)
+		if err != nil {
+			return fmt.Errorf("Gagal membaca kategori: %w", err)
+		}
+		for _, other := range all {
+			if other.ParentID == c.ID {
+				return clientError(http.StatusBadRequest, "invalid_parent", "Kategori yang punya sub-kategori tidak bisa dijadikan sub-kategori")
+			}
+		}
+	}
+	return nil
+}
+
+// ==========================
+// 🔹 Routing: /kategori (GET = daftar, POST = tambah, PATCH = ubah, DELETE = arsipkan)
+// ==========================
+func (s *Server) KategoriHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.GetKategori(w, r)
+	case http.MethodPost:
+		s.TambahKategori(w, r)
+	case http.MethodPatch:
+		s.EditKategori(w, r)
+	case http.MethodDelete:
+		s.ArsipkanKategori(w, r)
+	default:
+		http.Error(w, "Hanya GET, POST, PATCH atau DELETE method yang diizinkan", http.StatusMethodNotAllowed)
+	}
+}
+
+type categoryView struct {
+	Category
+	SubCategories []Category `json:"sub_categories"`
+}
+
+// GET /kategori?archived=true. Sub-kategori dikelompokkan di bawah
+// induknya; arsip hanya ikut jika archived=true.
+func (s *Server) GetKategori(w http.ResponseWriter, r *http.Request) {
+	includeArchived := false
+	if v := r.URL.Query().Get("archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "archived harus true atau false", http.StatusBadRequest)
+			return
+		}
+		includeArchived = b
+	}
+
+	caller := currentUser(r.Context())
+	list, err := s.store.Categories().List(context.Background(), caller.RoomID, includeArchived)
+	if err != nil {
+		http.Error(w, "Gagal mengambil kategori: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	views := []categoryView{}
+	index := map[int]int{}
+	for _, c := range list {
+		if c.ParentID == 0 {
+			index[c.ID] = len(views)
+			views = append(views, categoryView{Category: c, SubCategories: []Category{}})
+		}
+	}
+	for _, c := range list {
+		// Sub-kategori dari induk yang diarsipkan tidak ikut tampil
+		if i, ok := index[c.ParentID]; ok && c.ParentID != 0 {
+			views[i].SubCategories = append(views[i].SubCategories, c)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "success",
+		"total_data": len(views),
+		"kategori":   views,
+	})
+}
+
+func (s *Server) TambahKategori(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string `json:"name"`
+		Icon     string `json:"icon"`
+		Color    string `json:"color"`
+		ParentID int    `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	c := Category{RoomID: caller.RoomID, ParentID: req.ParentID, Name: req.Name, Icon: req.Icon, Color: req.Color}
+	if msg := validateCategoryFields(&c); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		if err := checkCategoryName(ctx, tx, c); err != nil {
+			return err
+		}
+		if err := checkCategoryParent(ctx, tx, c); err != nil {
+			return err
+		}
+		if err := tx.Categories().Create(ctx, &c); err != nil {
+			return fmt.Errorf("Gagal menambah kategori: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"message":  "Kategori berhasil ditambahkan",
+		"kategori": c,
+	})
+}
+
+// PATCH /kategori: ubah name/icon/color/parent_id, atau arsip lewat
+// archived. parent_id 0 menjadikannya kategori utama.
+func (s *Server) EditKategori(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID       int     `json:"id"`
+		Name     *string `json:"name,omitempty"`
+		Icon     *string `json:"icon,omitempty"`
+		Color    *string `json:"color,omitempty"`
+		ParentID *int    `json:"parent_id,omitempty"`
+		Archived *bool   `json:"archived,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.ID == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	var (
+		c       Category
+		renamed []Transaction
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		var err error
+		c, err = tx.Categories().Get(ctx, req.ID)
+		if errors.Is(err, ErrNotFound) || (err == nil && c.RoomID != caller.RoomID) {
+			return clientError(http.StatusNotFound, "not_found", "Kategori tidak ditemukan")
+		}
+		if err != nil {
+			return fmt.Errorf("Gagal membaca kategori: %w", err)
+		}
+		oldName := c.Name
+
+		if req.Name != nil {
+			c.Name = *req.Name
+		}
+		if req.Icon != nil {
+			c.Icon = *req.Icon
+		}
+		if req.Color != nil {
+			c.Color = *req.Color
+		}
+		if req.ParentID != nil {
+			c.ParentID = *req.ParentID
+		}
+		if req.Archived != nil {
+			switch {
+			case *req.Archived && c.ArchivedAt == nil:
+				now := time.Now()
+				c.ArchivedAt = &now
+			case !*req.Archived:
+				c.ArchivedAt = nil
+			}
+		}
+		if msg := validateCategoryFields(&c); msg != "" {
+			return clientError(http.StatusBadRequest, "", msg)
+		}
+		if err := checkCategoryName(ctx, tx, c); err != nil {
+			return err
+		}
+		if err := checkCategoryParent(ctx, tx, c); err != nil {
+			return err
+		}
+
+		if err := tx.Categories().Update(ctx, c); err != nil {
+			return fmt.Errorf("Gagal menyimpan kategori: %w", err)
+		}
+		// 🔹 Transaksi lama ikut memakai nama baru: versinya naik dan
+		// perubahannya tercatat di log audit seperti edit biasa
+		if c.Name == oldName {
+			return nil
+		}
+		var before []Transaction
+		if before, renamed, err = tx.Transactions().RenameCategory(ctx, c.ID, c.Name); err != nil {
+			return fmt.Errorf("Gagal memperbarui nama kategori di transaksi: %w", err)
+		}
+		for i := range renamed {
+			if err := recordAudit(ctx, tx, caller.ID, auditUpdate, &before[i], &renamed[i], 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":               "success",
+		"message":              "Kategori berhasil diperbarui",
+		"kategori":             c,
+		"transaksi_diperbarui": len(renamed),
+	})
+}
+
+// DELETE /kategori?id=N (atau {"id": N}) hanya mengarsipkan kategori.
+func (s *Server) ArsipkanKategori(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id == 0 {
+		var body struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+		id = body.ID
+	}
+	if id == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	var c Category
+	err = s.store.WithTx(ctx, func(tx Store) error {
+		var err error
+		c, err = tx.Categories().Get(ctx, id)
+		if errors.Is(err, ErrNotFound) || (err == nil && c.RoomID != caller.RoomID) {
+			return clientError(http.StatusNotFound, "not_found", "Kategori tidak ditemukan")
+		}
+		if err != nil {
+			return fmt.Errorf("Gagal membaca kategori: %w", err)
+		}
+		if c.ArchivedAt != nil {
+			return nil
+		}
+		now := time.Now()
+		c.ArchivedAt = &now
+		if err := tx.Categories().Update(ctx, c); err != nil {
+			return fmt.Errorf("Gagal mengarsipkan kategori: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"message":  "Kategori berhasil diarsipkan",
+		"kategori": c,
+	})
+}
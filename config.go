@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Konfigurasi aplikasi
+// ==========================
+//
+// Urutan prioritas: nilai default → file config JSON (opsional, path dari
+// CONFIG_FILE, default "config.json" jika ada) → environment variable.
+// Lihat config.example.json untuk contoh isi file.
+
+type Config struct {
+	Env string `json:"env"` // "development" atau "production"
+
+	DatabaseURL                 string `json:"database_url"`
+	DBMaxConns                  int32  `json:"db_max_conns"`
+	DBDisablePreparedStatements bool   `json:"db_disable_prepared_statements"`
+	AutoMigrate                 bool   `json:"auto_migrate"`
+
+	ListenAddr  string   `json:"listen_addr"`
+	Timezone    string   `json:"timezone"`
+	CORSOrigins []string `json:"cors_origins"`
+
+	TokenSecret     string   `json:"token_secret"`
+	AccessTokenTTL  duration `json:"access_token_ttl"`
+	RefreshTokenTTL duration `json:"refresh_token_ttl"`
+	InviteCodeTTL   duration `json:"invite_code_ttl"`
+
+	// Transaksi yang dihapus disimpan di tempat sampah selama TrashRetention,
+	// lalu dihapus permanen oleh job yang berjalan setiap TrashPurgeInterval.
+	TrashRetention     duration `json:"trash_retention"`
+	TrashPurgeInterval duration `json:"trash_purge_interval"`
+
+	// Respon POST dengan header Idempotency-Key disimpan selama ini.
+	IdempotencyKeyTTL duration `json:"idempotency_key_ttl"`
+
+	AdminToken string `json:"admin_token"`
+}
+
+// duration bisa ditulis sebagai string Go ("15m", "720h") di file JSON.
+type duration struct{ time.Duration }
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("durasi harus berupa string seperti \"15m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+var cfg Config
+
+func defaultConfig() Config {
+	return Config{
+		Env:             "development",
+		DBMaxConns:      5,
+		AutoMigrate:     true,
+		ListenAddr:      ":8080",
+		Timezone:        "Asia/Jakarta",
+		AccessTokenTTL:  duration{15 * time.Minute},
+		RefreshTokenTTL: duration{30 * 24 * time.Hour},
+		InviteCodeTTL:   duration{24 * time.Hour},
+
+		TrashRetention:     duration{30 * 24 * time.Hour},
+		TrashPurgeInterval: duration{time.Hour},
+
+		IdempotencyKeyTTL: duration{24 * time.Hour},
+	}
+}
+
+func loadConfig() (Config, error) {
+	c := defaultConfig()
+
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = "config.json"
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return c, fmt.Errorf("file config %s tidak valid: %w", path, err)
+		}
+	} else if explicit || !errors.Is(err, os.ErrNotExist) {
+		return c, fmt.Errorf("gagal membaca file config %s: %w", path, err)
+	}
+
+	var errs []error
+	envString := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	envDuration := func(key string, dst *duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			dst.Duration = d
+		}
+	}
+	envBool := func(key string, dst *bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			*dst = b
+		}
+	}
+
+	envString("APP_ENV", &c.Env)
+	envString("DATABASE_URL", &c.DatabaseURL)
+	if v, ok := os.LookupEnv("DB_MAX_CONNS"); ok {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DB_MAX_CONNS: %w", err))
+		}
+		c.DBMaxConns = int32(n)
+	}
+	envBool("DB_DISABLE_PREPARED_STATEMENTS", &c.DBDisablePreparedStatements)
+	envBool("AUTO_MIGRATE", &c.AutoMigrate)
+	envString("LISTEN_ADDR", &c.ListenAddr)
+	if port, ok := os.LookupEnv("PORT"); ok && os.Getenv("LISTEN_ADDR") == "" {
+		c.ListenAddr = ":" + port
+	}
+	envString("APP_TIMEZONE", &c.Timezone)
+	if v, ok := os.LookupEnv("CORS_ORIGINS"); ok {
+		c.CORSOrigins = nil
+		for _, o := range strings.Split(v, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				c.CORSOrigins = append(c.CORSOrigins, o)
+			}
+		}
+	}
+	envString("TOKEN_SECRET", &c.TokenSecret)
+	envDuration("ACCESS_TOKEN_TTL", &c.AccessTokenTTL)
+	envDuration("REFRESH_TOKEN_TTL", &c.RefreshTokenTTL)
+	envDuration("INVITE_CODE_TTL", &c.InviteCodeTTL)
+	envDuration("TRASH_RETENTION", &c.TrashRetention)
+	envDuration("TRASH_PURGE_INTERVAL", &c.TrashPurgeInterval)
+	envDuration("IDEMPOTENCY_KEY_TTL", &c.IdempotencyKeyTTL)
+	envString("ADMIN_TOKEN", &c.AdminToken)
+
+	errs = append(errs, c.validate()...)
+	return c, errors.Join(errs...)
+}
+
+func (c Config) validate() []error {
+	var errs []error
+
+	if c.Env != "development" && c.Env != "production" {
+		errs = append(errs, fmt.Errorf("env harus \"development\" atau \"production\", bukan %q", c.Env))
+	}
+	if c.DatabaseURL == "" {
+		errs = append(errs, errors.New("DATABASE_URL wajib diisi"))
+	}
+	if c.DBMaxConns <= 0 {
+		errs = append(errs, errors.New("DB_MAX_CONNS harus lebih dari 0"))
+	}
+	if c.ListenAddr == "" {
+		errs = append(errs, errors.New("LISTEN_ADDR wajib diisi"))
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("APP_TIMEZONE %q tidak dikenal: %w", c.Timezone, err))
+	}
+	if c.Env == "production" && len(c.TokenSecret) < 32 {
+		errs = append(errs, errors.New("TOKEN_SECRET minimal 32 karakter di production"))
+	}
+	if c.AccessTokenTTL.Duration <= 0 || c.RefreshTokenTTL.Duration <= 0 || c.InviteCodeTTL.Duration <= 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_TTL, REFRESH_TOKEN_TTL dan INVITE_CODE_TTL harus lebih dari 0"))
+	}
+	if c.TrashRetention.Duration <= 0 || c.TrashPurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("TRASH_RETENTION dan TRASH_PURGE_INTERVAL harus lebih dari 0"))
+	}
+	if c.IdempotencyKeyTTL.Duration <= 0 {
+		errs = append(errs, errors.New("IDEMPOTENCY_KEY_TTL harus lebih dari 0"))
+	}
+	if c.AccessTokenTTL.Duration >= c.RefreshTokenTTL.Duration {
+		errs = append(errs, errors.New("ACCESS_TOKEN_TTL harus lebih pendek dari REFRESH_TOKEN_TTL"))
+	}
+	for _, o := range c.CORSOrigins {
+		if o != "*" && !strings.HasPrefix(o, "http://") && !strings.HasPrefix(o, "https://") {
+			errs = append(errs, fmt.Errorf("CORS origin %q harus diawali http:// atau https://", o))
+		}
+	}
+	return errs
+}
+
+// applyConfig memasang nilai config ke variabel global yang dipakai handler.
+func applyConfig(c Config) {
+	cfg = c
+
+	loc, _ = time.LoadLocation(c.Timezone)
+
+	if c.TokenSecret != "" {
+		tokenSecret = []byte(c.TokenSecret)
+	} else {
+		// Hanya terjadi di development: token tidak berlaku lagi setelah restart.
+		tokenSecret = make([]byte, 32)
+		if _, err := rand.Read(tokenSecret); err != nil {
+			log.Fatalf("❌ Gagal membuat token secret: %v\n", err)
+		}
+		log.Println("⚠️ TOKEN_SECRET belum di-set, memakai secret acak sementara")
+	}
+}
+
+// ==========================
+// 🔹 Middleware: CORS
+// ==========================
+func withCORS(origins []string, next http.Handler) http.Handler {
+	allowAll := false
+	allowed := map[string]bool{}
+	for _, o := range origins {
+		if o == "*" {
+			allowAll = true
+		}
+		allowed[o] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && (allowAll || allowed[origin]) {
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, Idempotency-Key")
+			h.Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
+			h.Set("Access-Control-Max-Age", "600")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
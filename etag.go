@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ==========================
+// 🔹 Optimistic concurrency (versi transaksi)
+// ==========================
+//
+// Setiap transaksi punya kolom version yang naik di setiap perubahan dan
+// dikirim ke client sebagai ETag ("<id>-<version>"). Edit wajib membawa
+// versi yang terakhir dilihat client, lewat header If-Match atau field
+// "version" di body. Jika versi sudah berubah, edit ditolak dengan data
+// terkini:
+//
+//   - If-Match tidak cocok      → 412 precondition_failed
+//   - field version tidak cocok → 409 version_conflict
+//   - tidak ada keduanya        → 428 version_required
+
+// transactionETag membentuk ETag sebuah transaksi.
+func transactionETag(t Transaction) string {
+	return fmt.Sprintf(`"%d-%d"`, t.ID, t.Version)
+}
+
+// setTransactionETag memasang header ETag untuk respon transaksi tunggal.
+func setTransactionETag(w http.ResponseWriter, t Transaction) {
+	w.Header().Set("ETag", transactionETag(t))
+}
+
+// precondition adalah versi yang diharapkan client saat mengedit.
+type precondition struct {
+	ifMatch []string // daftar ETag dari header If-Match
+	version int      // dari field "version" di body (0 = tidak dikirim)
+}
+
+// readPrecondition mengambil If-Match atau field version. Mengembalikan
+// false (dan menulis respon 428) jika client tidak mengirim keduanya.
+func readPrecondition(w http.ResponseWriter, r *http.Request, bodyVersion *int) (precondition, bool) {
+	var p precondition
+	if h := r.Header.Get("If-Match"); h != "" {
+		for _, tag := range strings.Split(h, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag != "" {
+				p.ifMatch = append(p.ifMatch, tag)
+			}
+		}
+	}
+	if bodyVersion != nil {
+		p.version = *bodyVersion
+	}
+
+	if len(p.ifMatch) == 0 && p.version <= 0 {
+		writeError(w, http.StatusPreconditionRequired, "version_required",
+			"Sertakan header If-Match atau field version dari data transaksi terakhir")
+		return p, false
+	}
+	return p, true
+}
+
+// check dipanggil di dalam WithTx setelah baris transaksi dikunci, jadi
+// versi yang dibandingkan adalah versi terbaru di database.
+func (p precondition) check(current Transaction) error {
+	data := map[string]interface{}{
+		"current": current,
+		"etag":    transactionETag(current),
+	}
+
+	if len(p.ifMatch) > 0 {
+		etag := transactionETag(current)
+		for _, tag := range p.ifMatch {
+			if tag == etag {
+				return nil
+			}
+		}
+		return &httpError{
+			Status:  http.StatusPreconditionFailed,
+			Code:    "precondition_failed",
+			Message: "Transaksi sudah diubah orang lain, muat ulang data terbaru lalu coba lagi",
+			Data:    data,
+		}
+	}
+
+	if p.version != current.Version {
+		return &httpError{
+			Status:  http.StatusConflict,
+			Code:    "version_conflict",
+			Message: fmt.Sprintf("Versi transaksi sudah %d, bukan %d; muat ulang data terbaru lalu coba lagi", current.Version, p.version),
+			Data:    data,
+		}
+	}
+	return nil
+}
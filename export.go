@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Export CSV transaksi room
+// ==========================
+//
+// GET /transaksi/export menulis seluruh transaksi room (urut occurred_at,
+// terlama dulu) sebagai CSV. Filter sama dengan listing (from, to, jenis,
+// kategori, q, tags, ...; lihat listing.go) tapi tanpa limit. Opsi:
+//
+//	?columns=tanggal,jenis,nominal   kolom & urutannya (default exportDefaultColumns)
+//	?delimiter=comma|semicolon|tab|pipe
+//
+// Tanggal ditulis di zona waktu room, nominal ditulis eksak dengan dua
+// angka desimal. Baris dibaca dari cursor DB dan langsung ditulis ke
+// respon, jadi room dengan banyak transaksi tidak perlu dimuat ke memori.
+
+// exportFlushEvery menentukan seberapa sering baris di-flush ke client.
+const exportFlushEvery = 200
+
+// exportRow adalah satu transaksi beserta data pendukung untuk ditulis.
+type exportRow struct {
+	t     Transaction
+	tz    *time.Location
+	users map[int]string
+}
+
+// exportColumns adalah kolom yang bisa dipilih lewat ?columns=.
+var exportColumns = map[string]func(r exportRow) string{
+	"id":          func(r exportRow) string { return strconv.Itoa(r.t.ID) },
+	"tanggal":     func(r exportRow) string { return r.t.OccurredAt.In(r.tz).Format("2006-01-02") },
+	"waktu":       func(r exportRow) string { return r.t.OccurredAt.In(r.tz).Format("15:04:05") },
+	"occurred_at": func(r exportRow) string { return r.t.OccurredAt.In(r.tz).Format(time.RFC3339) },
+	"jenis":       func(r exportRow) string { return r.t.Jenis },
+	"kategori":    func(r exportRow) string { return csvText(r.t.Kategori) },
+	"nominal":     func(r exportRow) string { return r.t.Nominal.Fixed() },
+	"pemasukan": func(r exportRow) string {
+		p, _ := legacyColumns(r.t)
+		return p.Fixed()
+	},
+	"pengeluaran": func(r exportRow) string {
+		_, p := legacyColumns(r.t)
+		return p.Fixed()
+	},
+	"keterangan": func(r exportRow) string { return csvText(r.t.Keterangan) },
+	"tags":       func(r exportRow) string { return csvText(strings.Join(r.t.Tags, ", ")) },
+	"user_id":    func(r exportRow) string { return strconv.Itoa(r.t.UserID) },
+	"user":       func(r exportRow) string { return csvText(r.users[r.t.UserID]) },
+	"created_at": func(r exportRow) string { return r.t.CreatedAt.In(r.tz).Format(time.RFC3339) },
+	"updated_at": func(r exportRow) string { return r.t.UpdatedAt.In(r.tz).Format(time.RFC3339) },
+}
+
+var exportDefaultColumns = []string{"tanggal", "jenis", "kategori", "nominal", "keterangan", "tags", "user"}
+
+var exportDelimiters = map[string]rune{
+	"comma":     ',',
+	"semicolon": ';',
+	"tab":       '\t',
+	"pipe":      '|',
+}
+
+// csvText mencegah teks bebas dibaca sebagai formula oleh spreadsheet.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (s *Server) ExportTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	columns := exportDefaultColumns
+	if v := q.Get("columns"); v != "" {
+		columns = nil
+		for _, c := range strings.Split(v, ",") {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if _, ok := exportColumns[c]; !ok {
+				writeError(w, http.StatusBadRequest, "invalid_column", "Kolom tidak dikenal: "+c)
+				return
+			}
+			columns = append(columns, c)
+		}
+	}
+	delimiter := ','
+	if v := q.Get("delimiter"); v != "" {
+		d, ok := exportDelimiters[strings.ToLower(v)]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_delimiter", "delimiter harus comma, semicolon, tab atau pipe")
+			return
+		}
+		delimiter = d
+	}
+
+	ctx := context.Background()
+	caller := currentUser(r.Context())
+	filter, err := s.readLedgerQuery(ctx, r, caller.RoomID)
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+	filter.Limit, filter.After = 0, nil
+	if q.Get("order") == "" {
+		filter.Ascending = true
+	}
+
+	room, err := s.store.Rooms().Get(ctx, caller.RoomID)
+	if err != nil {
+		http.Error(w, "Gagal membaca room: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	members, err := s.store.Users().ListByRoom(ctx, caller.RoomID)
+	if err != nil {
+		http.Error(w, "Gagal membaca anggota room: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	users := make(map[int]string, len(members))
+	for _, m := range members {
+		users[m.ID] = m.FullName
+	}
+	tz := roomLocation(room)
+
+	filename := fmt.Sprintf("transaksi-room-%d-%s.csv", room.ID, time.Now().In(tz).Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+	cw.Write(columns)
+
+	flusher, _ := w.(http.Flusher)
+	n := 0
+	record := make([]string, len(columns))
+	err = s.store.Transactions().Each(ctx, filter, func(t Transaction) error {
+		row := exportRow{t: t, tz: tz, users: users}
+		for i, c := range columns {
+			record[i] = exportColumns[c](row)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			cw.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return cw.Error()
+	})
+	cw.Flush()
+	if err != nil {
+		// Header sudah terkirim; client akan menerima CSV yang terpotong.
+		log.Printf("⚠️ Export CSV room %d terhenti setelah %d baris: %v\n", room.ID, n, err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportTransaksi(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", map[string]interface{}{"timezone": "Asia/Jakarta"})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 100000, "occurred_at": "2026-03-01 08:00"})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 12500, "occurred_at": "2026-03-02 19:30", "keterangan": "=SUM(A1)", "tags": []string{"b", "a"}})
+
+	rec := ts.request("GET", "/transaksi/export", u.Token, nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("export: %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "attachment") {
+		t.Fatalf("Content-Disposition: %q", rec.Header().Get("Content-Disposition"))
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("CSV: %v %v", err, records)
+	}
+	header := map[string]int{}
+	for i, h := range records[0] {
+		header[h] = i
+	}
+	row := records[2] // urut terlama dulu
+	if row[header["tanggal"]] != "2026-03-02" || row[header["nominal"]] != "12500.00" || row[header["user"]] != "Andi" {
+		t.Fatalf("baris export: %v", row)
+	}
+	if row[header["keterangan"]] != "'=SUM(A1)" || row[header["tags"]] != "a, b" {
+		t.Fatalf("keterangan/tags: %v", row)
+	}
+
+	rec = ts.request("GET", "/transaksi/export?columns=id,jenis,nominal&delimiter=semicolon&jenis=Pengeluaran", u.Token, nil)
+	r := csv.NewReader(rec.Body)
+	r.Comma = ';'
+	records, err = r.ReadAll()
+	if err != nil || len(records) != 2 || strings.Join(records[0], ";") != "id;jenis;nominal" || records[1][1] != "Pengeluaran" {
+		t.Fatalf("export kolom pilihan: %v %v", err, records)
+	}
+
+	for q, code := range map[string]string{
+		"columns=id,password": "invalid_column",
+		"delimiter=spasi":     "invalid_delimiter",
+		"sort=nama":           "invalid_filter",
+	} {
+		if rec := ts.request("GET", "/transaksi/export?"+q, u.Token, nil); rec.Code != http.StatusBadRequest || errorCode(t, rec) != code {
+			t.Fatalf("%s: %d %s", q, rec.Code, rec.Body.String())
+		}
+	}
+}
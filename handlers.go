@@ -2,10 +2,10 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -13,12 +13,7 @@ import (
 
 // Hapus struct RegisterRequest di sini (pakai dari models.go)
 
-func hashPassword(pw string) string {
-	hash := sha256.Sum256([]byte(pw))
-	return hex.EncodeToString(hash[:])
-}
-
-func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
 		return
@@ -36,28 +31,19 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var count int
-	err = DB.QueryRow(context.Background(), "SELECT COUNT(*) FROM users WHERE room_id=$1", req.RoomID).Scan(&count)
+	// 🔹 User baru belum punya room; room dibuat lewat POST /rooms
+	// atau bergabung lewat kode undangan di POST /rooms/join
+	passwordHash, err := hashPassword(req.Password)
 	if err != nil {
-		http.Error(w, "Gagal memeriksa room", http.StatusInternalServerError)
+		http.Error(w, "Gagal memproses password", http.StatusInternalServerError)
 		return
 	}
 
-	if count >= 2 {
-		http.Error(w, fmt.Sprintf("Room %d sudah penuh", req.RoomID), http.StatusBadRequest)
-		return
-	}
-
-	passwordHash := hashPassword(req.Password)
-	_, err = DB.Exec(context.Background(),
-		`INSERT INTO users (full_name, email, password_hash, room_id, created_at)
-		 VALUES ($1, $2, $3, $4, $5)`,
-		strings.TrimSpace(req.FullName),
-		strings.ToLower(req.Email),
-		passwordHash,
-		req.RoomID,
-		time.Now(),
-	)
+	err = s.store.Users().Create(context.Background(), &User{
+		FullName:     strings.TrimSpace(req.FullName),
+		Email:        strings.ToLower(req.Email),
+		PasswordHash: passwordHash,
+	})
 
 	if err != nil {
 		http.Error(w, "Gagal menyimpan data: "+err.Error(), http.StatusInternalServerError)
@@ -66,10 +52,12 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	fmt.Fprintf(w, `{"message": "Registrasi berhasil untuk %s di Room %d"}`, req.FullName, req.RoomID)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": fmt.Sprintf("Registrasi berhasil untuk %s", req.FullName),
+	})
 }
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
 		return
@@ -91,273 +79,201 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx := context.Background()
+
 	// 🔹 Ambil user
-	var storedHash, fullName, email string
-	var roomID, userID int
-	queryUser := `
-		SELECT id, password_hash, full_name, email, room_id
-		FROM users
-		WHERE LOWER(email) = LOWER($1) OR LOWER(full_name) = LOWER($1)
-	`
-	err := DB.QueryRow(context.Background(), queryUser, req.Identifier).Scan(
-		&userID, &storedHash, &fullName, &email, &roomID,
-	)
+	user, err := s.store.Users().FindByIdentifier(ctx, req.Identifier)
 	if err != nil {
 		http.Error(w, "User tidak ditemukan", http.StatusUnauthorized)
 		return
 	}
 
 	// 🔹 Verifikasi password
-	inputHash := hashPassword(req.Password)
-	if inputHash != storedHash {
+	ok, needsRehash, err := verifyPassword(req.Password, user.PasswordHash)
+	if err != nil {
+		http.Error(w, "Gagal memverifikasi password", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
 		http.Error(w, "Password salah", http.StatusUnauthorized)
 		return
 	}
 
+	// 🔹 Upgrade hash lama (SHA-256 / parameter lama) ke argon2id
+	if needsRehash {
+		if newHash, err := hashPassword(req.Password); err == nil {
+			if err := s.store.Users().UpdatePasswordHash(ctx, user.ID, user.PasswordHash, newHash); err != nil {
+				log.Printf("⚠️ Gagal upgrade hash password user %d: %v\n", user.ID, err)
+			}
+		}
+	}
+
+	// 🔹 User belum punya room → cukup kirim token & data user
+	if user.RoomID == 0 {
+		tokens, err := issueTokens(ctx, s.store, user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"message":   "Login berhasil",
+			"user_id":   user.ID,
+			"user_name": user.FullName,
+			"email":     user.Email,
+			"room_id":   nil,
+			"time":      time.Now(),
+		}
+		for k, v := range tokens {
+			response[k] = v
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
 	// 🔹 Ambil info room
-	var (
-		roomName             string
-		tanggalBuatRoom      time.Time
-		totalPemasukanRoom   float64
-		totalPengeluaranRoom float64
-		totalSaldoRoom       float64
-		membersStr           string
-	)
-
-	queryRoom := `
-    SELECT 
-        r.room_name,
-        r.created_at,
-        COALESCE(rt.total_pemasukan_room, 0),
-        COALESCE(rt.total_pengeluaran_room, 0),
-        COALESCE(rb.total_saldo, 0),
-        COALESCE(m.members, '')
-    FROM rooms r
-    LEFT JOIN LATERAL (
-        SELECT 
-            SUM(pemasukan) AS total_pemasukan_room,
-            SUM(pengeluaran) AS total_pengeluaran_room
-        FROM user_transactions ut
-        WHERE ut.room_id = r.id
-    ) rt ON TRUE
-    LEFT JOIN room_balance rb ON rb.room_id = r.id
-    LEFT JOIN LATERAL (
-        SELECT STRING_AGG(full_name, ', ') AS members
-        FROM users u 
-        WHERE u.room_id = r.id
-    ) m ON TRUE
-    WHERE r.id = $1
-`
-	err = DB.QueryRow(context.Background(), queryRoom, roomID).Scan(
-		&roomName, &tanggalBuatRoom,
-		&totalPemasukanRoom, &totalPengeluaranRoom, &totalSaldoRoom, &membersStr,
-	)
+	room, err := s.store.Rooms().Get(ctx, user.RoomID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Gagal ambil data room: %v", err), http.StatusInternalServerError)
 		return
 	}
-
-	// ======================================================
-	// 🔹 Ambil semua pemasukan user + other_transaction (dengan id)
-	// ======================================================
-	var pemasukanHarian []map[string]interface{}
-
-	// dari user_transactions
-	pemasukanRows, err := DB.Query(context.Background(), `
-		SELECT id, tanggal_update::date, pemasukan
-		FROM user_transactions
-		WHERE user_id = $1 AND room_id = $2 AND pemasukan > 0
-		ORDER BY tanggal_update ASC
-	`, userID, roomID)
+	roomTotals, err := s.store.Transactions().Sum(ctx, LedgerFilter{RoomID: room.ID})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Gagal ambil pemasukan: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Gagal ambil data room: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer pemasukanRows.Close()
-
-	for pemasukanRows.Next() {
-		var (
-			id      int
-			tanggal time.Time
-			amount  float64
-		)
-		if err := pemasukanRows.Scan(&id, &tanggal, &amount); err == nil {
-			pemasukanHarian = append(pemasukanHarian, map[string]interface{}{
-				"id":        id,
-				"tanggal":   tanggal.Format("2006-01-02"),
-				"pemasukan": amount,
-				"sumber":    "user_transactions",
-			})
-		}
+	totalSaldoRoom, err := s.store.Balances().Get(ctx, room.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, fmt.Sprintf("Gagal ambil data room: %v", err), http.StatusInternalServerError)
+		return
 	}
-
-	// dari other_transaction
-	otherPemasukanRows, err := DB.Query(context.Background(), `
-		SELECT id, tanggal_update::date, nominal
-		FROM other_transaction
-		WHERE user_id = $1 AND room_id = $2 AND jenis = 'Pemasukan'
-		ORDER BY tanggal_update ASC
-	`, userID, roomID)
+	memberList, err := s.store.Users().ListByRoom(ctx, room.ID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Gagal ambil pemasukan lain: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Gagal ambil data room: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer otherPemasukanRows.Close()
-
-	for otherPemasukanRows.Next() {
-		var (
-			id      int
-			tanggal time.Time
-			nominal float64
-		)
-		if err := otherPemasukanRows.Scan(&id, &tanggal, &nominal); err == nil {
-			pemasukanHarian = append(pemasukanHarian, map[string]interface{}{
-				"id":        id,
-				"tanggal":   tanggal.Format("2006-01-02"),
-				"pemasukan": nominal,
-				"sumber":    "other_transaction",
-			})
-		}
+	members := make([]string, 0, len(memberList))
+	for _, m := range memberList {
+		members = append(members, m.FullName)
 	}
 
 	// ======================================================
-	// 🔹 Ambil semua pengeluaran user + other_transaction (dengan id)
+	// 🔹 Ambil semua pemasukan & pengeluaran user dari kedua ledger (dengan id)
 	// ======================================================
-	var pengeluaranHarian []map[string]interface{}
-
-	// dari user_transactions
-	pengeluaranRows, err := DB.Query(context.Background(), `
-		SELECT id, tanggal_update::date, pengeluaran
-		FROM user_transactions
-		WHERE user_id = $1 AND room_id = $2 AND pengeluaran > 0
-		ORDER BY tanggal_update ASC
-	`, userID, roomID)
+	pemasukanHarian, err := s.dailyLedger(ctx, user.ID, room, "Pemasukan")
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Gagal ambil pengeluaran: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Gagal ambil pemasukan: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer pengeluaranRows.Close()
-
-	for pengeluaranRows.Next() {
-		var (
-			id      int
-			tanggal time.Time
-			amount  float64
-		)
-		if err := pengeluaranRows.Scan(&id, &tanggal, &amount); err == nil {
-			pengeluaranHarian = append(pengeluaranHarian, map[string]interface{}{
-				"id":          id,
-				"tanggal":     tanggal.Format("2006-01-02"),
-				"pengeluaran": amount,
-				"sumber":      "user_transactions",
-			})
-		}
+	pengeluaranHarian, err := s.dailyLedger(ctx, user.ID, room, "Pengeluaran")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Gagal ambil pengeluaran: %v", err), http.StatusInternalServerError)
+		return
 	}
 
-	// dari other_transaction
-	otherPengeluaranRows, err := DB.Query(context.Background(), `
-		SELECT id, tanggal_update::date, nominal
-		FROM other_transaction
-		WHERE user_id = $1 AND room_id = $2 AND jenis = 'Pengeluaran'
-		ORDER BY tanggal_update ASC
-	`, userID, roomID)
+	// ======================================================
+	// 🔹 Buat token sesi
+	// ======================================================
+	tokens, err := issueTokens(ctx, s.store, user.ID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Gagal ambil pengeluaran lain: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer otherPengeluaranRows.Close()
-
-	for otherPengeluaranRows.Next() {
-		var (
-			id      int
-			tanggal time.Time
-			nominal float64
-		)
-		if err := otherPengeluaranRows.Scan(&id, &tanggal, &nominal); err == nil {
-			pengeluaranHarian = append(pengeluaranHarian, map[string]interface{}{
-				"id":          id,
-				"tanggal":     tanggal.Format("2006-01-02"),
-				"pengeluaran": nominal,
-				"sumber":      "other_transaction",
-			})
-		}
-	}
 
 	// ======================================================
 	// 🔹 Susun respon JSON
 	// ======================================================
 	response := map[string]interface{}{
-		"message":                 "Login berhasil",
-		"user_id":                 userID,
-		"user_name":               fullName,
-		"email":                   email,
-		"room_id":                 roomID,
-		"room_name":               roomName,
-		"tanggal_buat_room":       tanggalBuatRoom.Format("2006-01-02"),
-		"members":                 strings.Split(membersStr, ", "),
-		"time":                    time.Now(),
-		"pemasukan_harian":        pemasukanHarian,
-		"pengeluaran_harian":      pengeluaranHarian,
-		"total_pemasukan_room":    totalPemasukanRoom,
-		"total_pengeluaran_room":  totalPengeluaranRoom,
-		"total_room_saldo":        totalSaldoRoom,
+		"message":                "Login berhasil",
+		"user_id":                user.ID,
+		"user_name":              user.FullName,
+		"email":                  user.Email,
+		"room_id":                room.ID,
+		"room_name":              room.RoomName,
+		"currency":               room.Currency,
+		"timezone":               roomLocation(room).String(),
+		"tanggal_buat_room":      room.CreatedAt.In(roomLocation(room)).Format("2006-01-02"),
+		"members":                members,
+		"time":                   time.Now(),
+		"pemasukan_harian":       pemasukanHarian,
+		"pengeluaran_harian":     pengeluaranHarian,
+		"total_pemasukan_room":   roomTotals.Pemasukan,
+		"total_pengeluaran_room": roomTotals.Pengeluaran,
+		"total_room_saldo":       totalSaldoRoom,
+	}
+	for k, v := range tokens {
+		response[k] = v
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// dailyLedger menyusun daftar pemasukan/pengeluaran milik user, urut dari
+// occurred_at terlama. "tanggal" adalah tanggal occurred_at di zona waktu
+// room. jenis menentukan nama field nominal di setiap item; id dan sumber
+// tetap memakai id versi lama supaya client lama tidak berubah.
+func (s *Server) dailyLedger(ctx context.Context, userID int, room Room, jenis string) ([]map[string]interface{}, error) {
+	field := strings.ToLower(jenis)
+	filter := LedgerFilter{RoomID: room.ID, UserID: userID, Jenis: jenis, Ascending: true}
+	tz := roomLocation(room)
 
+	rows, err := s.store.Transactions().List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
+	var list []map[string]interface{}
+	for _, t := range rows {
+		id, sumber := legacyRef(t)
+		list = append(list, map[string]interface{}{
+			"id":             id,
+			"transaction_id": t.ID,
+			"tanggal":        t.OccurredAt.In(tz).Format("2006-01-02"),
+			"occurred_at":    t.OccurredAt.In(tz).Format(time.RFC3339),
+			field:            t.Nominal,
+			"kategori":       t.Kategori,
+			"sumber":         sumber,
+			"version":        t.Version,
+		})
+	}
+	return list, nil
+}
 
+// GetRoomsHandler hanya menampilkan room milik pemanggil (maksimal satu).
+func (s *Server) GetRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	caller := currentUser(r.Context())
+	ctx := context.Background()
 
-func GetRoomsHandler(w http.ResponseWriter, r *http.Request) {
-	query := `
-	SELECT 
-		r.id, 
-		r.room_name, 
-		r.created_at,
-		COUNT(u.id) AS user_count
-	FROM rooms r
-	LEFT JOIN users u ON u.room_id = r.id
-	GROUP BY r.id, r.room_name, r.created_at
-	ORDER BY r.id;
-	`
+	rooms := []map[string]interface{}{}
 
-	rows, err := DB.Query(context.Background(), query)
-	if err != nil {
+	room, err := s.store.Rooms().Get(ctx, caller.RoomID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
 		http.Error(w, "Gagal mengambil data room: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	var rooms []map[string]interface{}
-
-	for rows.Next() {
-		var (
-			id         int
-			roomName   string
-			createdAt  time.Time
-			userCount  int
-		)
-
-		err := rows.Scan(&id, &roomName, &createdAt, &userCount)
+	if err == nil {
+		userCount, err := s.store.Users().CountByRoom(ctx, room.ID)
 		if err != nil {
 			http.Error(w, "Gagal membaca data: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		status := "empty"
-		if userCount >= 2 {
+		if userCount >= maxRoomMembers {
 			status = "max"
 		}
 
 		rooms = append(rooms, map[string]interface{}{
-			"id":          id,
-			"room_name":   roomName,
-			"created_at":  createdAt,
-			"user_count":  userCount,
-			"status":      status,
+			"id":                      room.ID,
+			"room_name":               room.RoomName,
+			"created_at":              room.CreatedAt,
+			"user_count":              userCount,
+			"status":                  status,
+			"forbid_negative_balance": room.ForbidNegativeBalance,
+			"timezone":                roomLocation(room).String(),
 		})
 	}
 
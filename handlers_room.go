@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Satu room maksimal diisi sepasang user.
+const maxRoomMembers = 2
+
+// Huruf/angka yang mudah dibedakan saat dibacakan (tanpa 0/O, 1/I/L).
+const inviteAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// newInviteCode memilih tiap huruf dengan rand.Int supaya semua huruf
+// punya peluang yang sama (byte acak % 31 lebih sering jatuh di huruf awal).
+func newInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	n := big.NewInt(int64(len(inviteAlphabet)))
+	for i := range buf {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = inviteAlphabet[k.Int64()]
+	}
+	return string(buf), nil
+}
+
+// ==========================
+// 🔹 Routing: /rooms (GET = room saya, POST = buat room, PATCH = ubah pengaturan)
+// ==========================
+func (s *Server) RoomsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.GetRoomsHandler(w, r)
+	case http.MethodPost:
+		s.CreateRoomHandler(w, r)
+	case http.MethodPatch:
+		s.UpdateRoomSettingsHandler(w, r)
+	default:
+		http.Error(w, "Hanya GET, POST atau PATCH method yang diizinkan", http.StatusMethodNotAllowed)
+	}
+}
+
+// ==========================
+// 🔹 Handler: Buat Room
+// ==========================
+func (s *Server) CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RoomName              string `json:"room_name"`
+		Currency              string `json:"currency"`
+		ForbidNegativeBalance bool   `json:"forbid_negative_balance"`
+		Timezone              string `json:"timezone"` // opsional, contoh "Asia/Makassar"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
+
+	roomName := strings.TrimSpace(req.RoomName)
+	if roomName == "" {
+		http.Error(w, "room_name wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	if _, ok := currencyExponent[currency]; !ok {
+		http.Error(w, "Mata uang "+currency+" belum didukung", http.StatusBadRequest)
+		return
+	}
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone != "" {
+		if _, err := loadTimezone(timezone); err != nil {
+			http.Error(w, "timezone "+timezone+" tidak dikenal", http.StatusBadRequest)
+			return
+		}
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	room := Room{
+		RoomName:              roomName,
+		OwnerID:               caller.ID,
+		Currency:              currency,
+		ForbidNegativeBalance: req.ForbidNegativeBalance,
+		Timezone:              timezone,
+	}
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Kunci baris user supaya tidak bisa membuat dua room sekaligus
+		user, err := tx.Users().Lock(ctx, caller.ID)
+		if err != nil {
+			return fmt.Errorf("Gagal membaca user: %w", err)
+		}
+		if user.RoomID != 0 {
+			return clientError(http.StatusConflict, "already_in_room", "Kamu sudah tergabung di sebuah room")
+		}
+
+		if err := tx.Rooms().Create(ctx, &room); err != nil {
+			return fmt.Errorf("Gagal membuat room: %w", err)
+		}
+		if err := tx.Users().SetRoom(ctx, caller.ID, room.ID); err != nil {
+			return fmt.Errorf("Gagal memasukkan user ke room: %w", err)
+		}
+		if err := tx.Balances().Create(ctx, room.ID, 0); err != nil {
+			return fmt.Errorf("Gagal membuat saldo room: %w", err)
+		}
+		return seedDefaultCategories(ctx, tx, room.ID)
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":                  "success",
+		"message":                 "Room berhasil dibuat",
+		"room_id":                 room.ID,
+		"room_name":               room.RoomName,
+		"owner_id":                room.OwnerID,
+		"currency":                room.Currency,
+		"created_at":              room.CreatedAt,
+		"forbid_negative_balance": room.ForbidNegativeBalance,
+		"timezone":                roomLocation(room).String(),
+	})
+}
+
+// ==========================
+// 🔹 Handler: Ubah Pengaturan Room (khusus pemilik)
+// ==========================
+func (s *Server) UpdateRoomSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RoomName              *string `json:"room_name,omitempty"`
+		ForbidNegativeBalance *bool   `json:"forbid_negative_balance,omitempty"`
+		Timezone              *string `json:"timezone,omitempty"` // "" = kembali ke APP_TIMEZONE
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.RoomName != nil && strings.TrimSpace(*req.RoomName) == "" {
+		http.Error(w, "room_name tidak boleh kosong", http.StatusBadRequest)
+		return
+	}
+	if req.Timezone != nil {
+		*req.Timezone = strings.TrimSpace(*req.Timezone)
+		if _, err := loadTimezone(*req.Timezone); *req.Timezone != "" && err != nil {
+			http.Error(w, "timezone "+*req.Timezone+" tidak dikenal", http.StatusBadRequest)
+			return
+		}
+	}
+
+	caller := currentUser(r.Context())
+	if caller.RoomID == 0 {
+		writeError(w, http.StatusForbidden, "no_room", "Kamu belum tergabung di room manapun")
+		return
+	}
+	ctx := context.Background()
+
+	var room Room
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		var err error
+		room, err = tx.Rooms().Lock(ctx, caller.RoomID)
+		if err != nil {
+			return fmt.Errorf("Gagal membaca room: %w", err)
+		}
+		if room.OwnerID != caller.ID {
+			return clientError(http.StatusForbidden, "not_owner", "Hanya pemilik room yang boleh mengubah pengaturan")
+		}
+
+		if req.RoomName != nil {
+			room.RoomName = strings.TrimSpace(*req.RoomName)
+		}
+		if req.ForbidNegativeBalance != nil {
+			room.ForbidNegativeBalance = *req.ForbidNegativeBalance
+		}
+		if req.Timezone != nil {
+			room.Timezone = *req.Timezone
+		}
+		if err := tx.Rooms().UpdateSettings(ctx, room); err != nil {
+			return fmt.Errorf("Gagal menyimpan pengaturan room: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Pengaturan room berhasil disimpan",
+		"room":    room,
+	})
+}
+
+// ==========================
+// 🔹 Handler: Buat Kode Undangan
+// ==========================
+func (s *Server) CreateInviteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	count, err := s.store.Users().CountByRoom(ctx, caller.RoomID)
+	if err != nil {
+		http.Error(w, "Gagal memeriksa room: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if count >= maxRoomMembers {
+		writeError(w, http.StatusConflict, "room_full", "Room sudah penuh")
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		http.Error(w, "Gagal membuat kode undangan", http.StatusInternalServerError)
+		return
+	}
+	expiresAt := time.Now().Add(cfg.InviteCodeTTL.Duration)
+
+	err = s.store.Rooms().CreateInvite(ctx, RoomInvite{
+		Code:      code,
+		RoomID:    caller.RoomID,
+		CreatedBy: caller.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		http.Error(w, "Gagal menyimpan kode undangan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "success",
+		"invite_code": code,
+		"room_id":     caller.RoomID,
+		"expires_at":  expiresAt.In(loc).Format(time.RFC3339),
+	})
+}
+
+// ==========================
+// 🔹 Handler: Gabung Room dengan Kode Undangan
+// ==========================
+func (s *Server) JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Code string `json:"invite_code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		http.Error(w, "invite_code wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+
+	var room Room
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		user, err := tx.Users().Lock(ctx, caller.ID)
+		if err != nil {
+			return fmt.Errorf("Gagal membaca user: %w", err)
+		}
+		if user.RoomID != 0 {
+			return clientError(http.StatusConflict, "already_in_room", "Kamu sudah tergabung di sebuah room")
+		}
+
+		now := time.Now()
+		invite, err := tx.Rooms().LockValidInvite(ctx, code, now)
+		if errors.Is(err, ErrNotFound) {
+			return clientError(http.StatusNotFound, "invalid_invite", "Kode undangan tidak valid atau sudah kedaluwarsa")
+		}
+		if err != nil {
+			return fmt.Errorf("Gagal membaca kode undangan: %w", err)
+		}
+
+		// 🔹 Kunci baris room supaya dua join bersamaan diproses bergantian,
+		// sehingga pengecekan jumlah anggota di bawah selalu akurat
+		room, err = tx.Rooms().Lock(ctx, invite.RoomID)
+		if err != nil {
+			return fmt.Errorf("Gagal membaca room: %w", err)
+		}
+
+		count, err := tx.Users().CountByRoom(ctx, room.ID)
+		if err != nil {
+			return fmt.Errorf("Gagal memeriksa room: %w", err)
+		}
+		if count >= maxRoomMembers {
+			return clientError(http.StatusConflict, "room_full", "Room sudah penuh")
+		}
+
+		if err := tx.Users().SetRoom(ctx, caller.ID, room.ID); err != nil {
+			return fmt.Errorf("Gagal bergabung ke room: %w", err)
+		}
+		if err := tx.Rooms().MarkInviteUsed(ctx, code, caller.ID, now); err != nil {
+			return fmt.Errorf("Gagal memperbarui kode undangan: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"message":   "Berhasil bergabung ke room",
+		"room_id":   room.ID,
+		"room_name": room.RoomName,
+	})
+}
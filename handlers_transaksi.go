@@ -3,194 +3,220 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
-	"io"
-	"bytes"
 )
 
-// ===========================
-// 🔹 Handler: Tambah Pemasukan
-// ===========================
-var loc *time.Location
-
-func init() {
-    // Inisialisasi lokasi waktu Asia/Jakarta saat program dijalankan
-    var err error
-    loc, err = time.LoadLocation("Asia/Jakarta")
-    if err != nil {
-        loc = time.FixedZone("WIB", 7*3600) // fallback manual ke GMT+7
-    }
+// Zona waktu aplikasi, diisi dari config (APP_TIMEZONE) lewat applyConfig.
+// Default-nya WIB supaya tetap aman dipakai sebelum config dimuat.
+var loc = time.FixedZone("WIB", 7*3600)
+
+// Kategori tidak lagi tetap; lihat categories.go.
+var validJenis = map[string]bool{"Pemasukan": true, "Pengeluaran": true}
+
+// normalizeLabel menyeragamkan penulisan jenis ("  pemasukan" → "Pemasukan").
+func normalizeLabel(s string) string {
+	return strings.Title(strings.ToLower(strings.TrimSpace(s)))
 }
 
-func Pemasukan(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
-		return
+// legacyRef mengembalikan id dan nama tabel versi lama sebuah transaksi,
+// untuk client yang masih membedakan user_transactions dan other_transaction.
+func legacyRef(t Transaction) (int, string) {
+	if t.LegacyUserID != nil {
+		return *t.LegacyUserID, "user_transactions"
 	}
+	return t.ID, "other_transaction"
+}
 
-	type IncomeRequest struct {
-		UserID int     `json:"user_id"`
-		RoomID int     `json:"room_id"`
-		Amount float64 `json:"amount"`
+// legacyColumns memecah transaksi ke kolom pemasukan/pengeluaran versi
+// user_transactions.
+func legacyColumns(t Transaction) (pemasukan, pengeluaran Money) {
+	if t.Jenis == "Pengeluaran" {
+		return 0, t.Nominal
 	}
+	return t.Nominal, 0
+}
 
-	var req IncomeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
-		return
+// createTransaction menyimpan t dan menyesuaikan saldo room di dalam
+// transaksi DB tx. Saldo negatif ditolak jika room mengaktifkan
+// forbid_negative_balance; jika checkSaldo bernilai true, pengeluaran yang
+// melebihi saldo room juga ditolak di room tanpa aturan tersebut.
+func createTransaction(ctx context.Context, tx Store, t *Transaction, checkSaldo bool) (Money, error) {
+	// --- Ambil saldo saat ini (dikunci), buat jika belum ada ---
+	saldo, err := lockRoomBalance(ctx, tx, t.RoomID)
+	if err != nil {
+		return 0, err
 	}
 
-	if req.UserID == 0 || req.RoomID == 0 || req.Amount <= 0 {
-		http.Error(w, "Data tidak lengkap", http.StatusBadRequest)
-		return
+	// Aturan forbid_negative_balance room berlaku di semua jalur tambah
+	// (409 negative_balance); cek saldo cukup hanya untuk room tanpa aturan
+	saldo += t.Delta()
+	if err := guardNegativeBalance(ctx, tx, t.RoomID, saldo); err != nil {
+		return 0, err
+	}
+	if checkSaldo && t.Jenis == "Pengeluaran" && saldo < 0 {
+		return 0, clientError(http.StatusBadRequest, "", "Saldo Tidak Cukup")
 	}
 
-	query := `
-		INSERT INTO user_transactions (user_id, room_id, pemasukan, tanggal_update)
-		VALUES ($1, $2, $3, NOW())
-	`
-	_, err := DB.Exec(context.Background(), query,
-		req.UserID, req.RoomID, req.Amount,
-	)
-	if err != nil {
-		http.Error(w, "Gagal menambah pemasukan: "+err.Error(), http.StatusInternalServerError)
-		return
+	tags := t.Tags
+	if err := tx.Transactions().Create(ctx, t); err != nil {
+		return 0, fmt.Errorf("Gagal menambah transaksi: %w", err)
+	}
+	t.Tags = tags
+	if err := setTransactionTags(ctx, tx, t); err != nil {
+		return 0, err
 	}
 
-	// 🔹 Ambil saldo terkini per user dalam room tsb
-	summary := getUserSummary(req.RoomID, req.UserID)
+	// Update saldo
+	if err := setRoomBalance(ctx, tx, t.RoomID, saldo); err != nil {
+		return 0, err
+	}
+	return saldo, recordAudit(ctx, tx, t.UserID, auditCreate, nil, t, t.Delta())
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":   "success",
-		"message":  "Pemasukan berhasil ditambahkan",
-		"room_id":  req.RoomID,
-		"user_id":  req.UserID,
-		"summary":  summary,
-		"datetime": time.Now().In(loc).Format(time.RFC3339),
-	})
+// ===========================
+// 🔹 Handler: Tambah Pemasukan / Pengeluaran (endpoint lama)
+// ===========================
+//
+// Keduanya sekarang hanya shim di atas tabel transactions: transaksi tanpa
+// kategori, dengan nomor legacy_user_id supaya /edit-transaksi-user dan
+// /hapus-transaksi-user tetap bisa dipakai client lama.
+func (s *Server) Pemasukan(w http.ResponseWriter, r *http.Request) {
+	s.tambahLegacy(w, r, "Pemasukan")
 }
 
-// ============================
-// 🔹 Handler: Tambah Pengeluaran
-// ============================
-func Pengeluaran(w http.ResponseWriter, r *http.Request) {
+func (s *Server) Pengeluaran(w http.ResponseWriter, r *http.Request) {
+	s.tambahLegacy(w, r, "Pengeluaran")
+}
+
+func (s *Server) tambahLegacy(w http.ResponseWriter, r *http.Request, jenis string) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
 		return
 	}
 
-	type ExpenseRequest struct {
-		UserID int     `json:"user_id"`
-		RoomID int     `json:"room_id"`
-		Amount float64 `json:"amount"`
+	type AmountRequest struct {
+		Amount     Money  `json:"amount"`
+		OccurredAt string `json:"occurred_at"` // opsional, default sekarang
 	}
 
-	var req ExpenseRequest
+	var req AmountRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
 		return
 	}
 
-	if req.UserID == 0 || req.RoomID == 0 || req.Amount <= 0 {
-		http.Error(w, "Data tidak lengkap", http.StatusBadRequest)
+	caller := currentUser(r.Context())
+	if msg := validateAmount(req.Amount, caller.Currency); msg != "" {
+		http.Error(w, "Data tidak lengkap: "+msg, http.StatusBadRequest)
 		return
 	}
 
-	query := `
-		INSERT INTO user_transactions (user_id, room_id, pengeluaran, tanggal_update)
-		VALUES ($1, $2, $3, NOW())
-	`
-	_, err := DB.Exec(context.Background(), query,
-		req.UserID, req.RoomID, req.Amount,
-	)
+	ctx := context.Background()
+	var occurredAt time.Time
+	if req.OccurredAt != "" {
+		var err error
+		if occurredAt, err = s.roomOccurredAt(ctx, caller.RoomID, req.OccurredAt); err != nil {
+			writeTxError(w, err)
+			return
+		}
+	}
+
+	var totalSaldo Money
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		legacyID, err := tx.Transactions().NextLegacyUserID(ctx)
+		if err != nil {
+			return fmt.Errorf("Gagal menambah %s: %w", strings.ToLower(jenis), err)
+		}
+
+		// 🔹 Saldo room ikut berubah di transaksi DB yang sama
+		t := Transaction{
+			UserID:       caller.ID,
+			RoomID:       caller.RoomID,
+			Jenis:        jenis,
+			Nominal:      req.Amount,
+			OccurredAt:   occurredAt,
+			LegacyUserID: &legacyID,
+		}
+		totalSaldo, err = createTransaction(ctx, tx, &t, false)
+		return err
+	})
 	if err != nil {
-		http.Error(w, "Gagal menambah pengeluaran: "+err.Error(), http.StatusInternalServerError)
+		writeTxError(w, err)
 		return
 	}
 
 	// 🔹 Ambil saldo terkini per user dalam room tsb
-	summary := getUserSummary(req.RoomID, req.UserID)
+	summary := s.getUserSummary(caller.RoomID, caller.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":   "success",
-		"message":  "Pengeluaran berhasil ditambahkan",
-		"room_id":  req.RoomID,
-		"user_id":  req.UserID,
-		"summary":  summary,
-		"datetime": time.Now().In(loc).Format(time.RFC3339),
+		"status":      "success",
+		"message":     jenis + " berhasil ditambahkan",
+		"room_id":     caller.RoomID,
+		"user_id":     caller.ID,
+		"summary":     summary,
+		"total_saldo": totalSaldo,
+		"datetime":    time.Now().In(loc).Format(time.RFC3339),
 	})
 }
 
-// ==========================
-// 🔹 Fungsi: Hitung Ringkasan Per User dalam Room
-// ==========================
-// ==========================
-// 🔹 Fungsi: Hitung Ringkasan Room dan User
-// ==========================
-func getUserSummary(roomID, userID int) map[string]interface{} {
+// ledgerSummary adalah total room dan total satu user. Dipakai
+// getUserSummary dan laporan bulanan (statement.go) supaya angka di laporan
+// sama dengan di aplikasi.
+type ledgerSummary struct {
+	Room LedgerTotals
+	User LedgerTotals
+	Tags []TagTotals
+}
+
+// summarize menghitung ledgerSummary untuk transaksi yang cocok dengan f
+// (f.UserID diabaikan; total user memakai userID).
+func (s *Server) summarize(ctx context.Context, f LedgerFilter, userID int) (ledgerSummary, error) {
 	var (
-		totalPemasukanRoom   float64
-		totalPengeluaranRoom float64
-		totalSaldoRoom       float64
-		terakhirUpdateRoom   *time.Time
-
-		totalPemasukanUser   float64
-		totalPengeluaranUser float64
-		totalSaldoUser       float64
+		sum ledgerSummary
+		err error
 	)
 
 	// 🔹 Ambil total untuk seluruh user di dalam room
-	queryRoom := `
-		SELECT 
-			COALESCE(SUM(pemasukan), 0),
-			COALESCE(SUM(pengeluaran), 0),
-			(COALESCE(SUM(pemasukan), 0) - COALESCE(SUM(pengeluaran), 0)) AS total_saldo,
-			MAX(tanggal_update)
-		FROM user_transactions
-		WHERE room_id = $1
-	`
-
-	err := DB.QueryRow(context.Background(), queryRoom, roomID).Scan(
-		&totalPemasukanRoom,
-		&totalPengeluaranRoom,
-		&totalSaldoRoom,
-		&terakhirUpdateRoom,
-	)
-	if err != nil {
-		return map[string]interface{}{
-			"error": fmt.Sprintf("Gagal menghitung total room: %v", err),
-		}
+	f.UserID = 0
+	if sum.Room, err = s.store.Transactions().Sum(ctx, f); err != nil {
+		return sum, fmt.Errorf("Gagal menghitung total room: %w", err)
 	}
 
 	// 🔹 Ambil total untuk user spesifik dalam room
-	queryUser := `
-		SELECT 
-			COALESCE(SUM(pemasukan), 0),
-			COALESCE(SUM(pengeluaran), 0),
-			(COALESCE(SUM(pemasukan), 0) - COALESCE(SUM(pengeluaran), 0)) AS total_saldo
-		FROM user_transactions
-		WHERE room_id = $1 AND user_id = $2
-	`
-
-	err = DB.QueryRow(context.Background(), queryUser, roomID, userID).Scan(
-		&totalPemasukanUser,
-		&totalPengeluaranUser,
-		&totalSaldoUser,
-	)
+	userFilter := f
+	userFilter.UserID = userID
+	if sum.User, err = s.store.Transactions().Sum(ctx, userFilter); err != nil {
+		return sum, fmt.Errorf("Gagal menghitung saldo user: %w", err)
+	}
+
+	// 🔹 Rincian per tag (transaksi dengan beberapa tag dihitung di tiap tag)
+	if sum.Tags, err = s.store.Tags().Totals(ctx, f); err != nil {
+		return sum, fmt.Errorf("Gagal menghitung total per tag: %w", err)
+	}
+	return sum, nil
+}
+
+// ==========================
+// 🔹 Fungsi: Hitung Ringkasan Room dan User
+// ==========================
+func (s *Server) getUserSummary(roomID, userID int) map[string]interface{} {
+	sum, err := s.summarize(context.Background(), LedgerFilter{RoomID: roomID}, userID)
 	if err != nil {
 		return map[string]interface{}{
-			"error": fmt.Sprintf("Gagal menghitung saldo user: %v", err),
+			"error": err.Error(),
 		}
 	}
+	room, user := sum.Room, sum.User
 
 	var formattedTime any
-	if terakhirUpdateRoom != nil {
-		formattedTime = terakhirUpdateRoom.Format("2006-01-02 15:04:05")
+	if room.LastUpdate != nil {
+		formattedTime = room.LastUpdate.Format("2006-01-02 15:04:05")
 	} else {
 		formattedTime = nil
 	}
@@ -198,635 +224,740 @@ func getUserSummary(roomID, userID int) map[string]interface{} {
 	return map[string]interface{}{
 		// 🔹 Total untuk seluruh room
 		"room_summary": map[string]interface{}{
-			"total_pemasukan_room":   totalPemasukanRoom,
-			"total_pengeluaran_room": totalPengeluaranRoom,
-			"total_saldo_room":       totalSaldoRoom,
+			"total_pemasukan_room":   room.Pemasukan,
+			"total_pengeluaran_room": room.Pengeluaran,
+			"total_saldo_room":       room.Saldo(),
 			"terakhir_update_room":   formattedTime,
 		},
 
 		// 🔹 Total untuk user yang baru input
 		"user_summary": map[string]interface{}{
-			"total_pemasukan_user":   totalPemasukanUser,
-			"total_pengeluaran_user": totalPengeluaranUser,
-			"total_saldo_user":       totalSaldoUser,
+			"total_pemasukan_user":   user.Pemasukan,
+			"total_pengeluaran_user": user.Pengeluaran,
+			"total_saldo_user":       user.Saldo(),
 		},
+
+		// 🔹 Total per tag di room
+		"tag_summary": sum.Tags,
 	}
 }
 
-func TambahTransaksi(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodPost {
-        http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
-        return
-    }
-
-    type TransactionRequest struct {
-        UserID     int     `json:"user_id"`
-        RoomID     int     `json:"room_id"`
-        Jenis      string  `json:"jenis"`
-        Kategori   string  `json:"kategori"`
-        Nominal    float64 `json:"nominal"`
-        Keterangan string  `json:"keterangan"`
-    }
-
-    bodyBytes, _ := io.ReadAll(r.Body)
-    fmt.Println("RAW BODY:", string(bodyBytes))
-    r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-
-    var req TransactionRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "JSON tidak valid", http.StatusBadRequest)
-        return
-    }
-
-    jenis := strings.Title(strings.ToLower(strings.TrimSpace(req.Jenis)))
-    kategori := strings.Title(strings.ToLower(strings.TrimSpace(req.Kategori)))
-    validJenis := map[string]bool{"Pemasukan": true, "Pengeluaran": true}
-    validKategori := map[string]bool{"Makanan": true, "Belanja": true, "Hiburan": true, "Tagihan": true, "Lainnya": true}
-
-    if req.UserID == 0 || req.RoomID == 0 || req.Nominal <= 0 || !validJenis[jenis] || !validKategori[kategori] {
-        http.Error(w, "Data tidak lengkap atau salah", http.StatusBadRequest)
-        return
-    }
-
-    tx, err := DB.Begin(context.Background())
-    if err != nil {
-        http.Error(w, "Gagal memulai transaksi DB: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-    defer tx.Rollback(context.Background())
-
-    // --- Ambil saldo saat ini, buat jika belum ada ---
-    var currentSaldo float64
-    err = tx.QueryRow(context.Background(), "SELECT total_saldo FROM room_balance WHERE room_id=$1 FOR UPDATE", req.RoomID).Scan(&currentSaldo)
-    if err != nil {
-        // Jika tidak ada record → buat baru
-        if strings.Contains(err.Error(), "no rows in result set") {
-            _, err := tx.Exec(context.Background(), "INSERT INTO room_balance (room_id, total_saldo) VALUES ($1, 0)", req.RoomID)
-            if err != nil {
-                http.Error(w, "Gagal membuat saldo baru: "+err.Error(), http.StatusInternalServerError)
-                return
-            }
-            currentSaldo = 0
-        } else {
-            http.Error(w, "Gagal membaca saldo: "+err.Error(), http.StatusInternalServerError)
-            return
-        }
-    }
-
-    // Cek saldo cukup untuk pengeluaran
-    if jenis == "Pengeluaran" && currentSaldo < req.Nominal {
-        http.Error(w, "Saldo Tidak Cukup", http.StatusBadRequest)
-        return
-    }
-
-    // Insert transaksi
-    insertQuery := `
-        INSERT INTO other_transaction (user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update)
-        VALUES ($1, $2, $3, $4, $5, $6, NOW())
-    `
-    _, err = tx.Exec(context.Background(), insertQuery, req.UserID, req.RoomID, jenis, kategori, req.Nominal, req.Keterangan)
-    if err != nil {
-        http.Error(w, "Gagal menambah transaksi: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // Update saldo
-    if jenis == "Pemasukan" {
-        currentSaldo += req.Nominal
-    } else if jenis == "Pengeluaran" {
-        currentSaldo -= req.Nominal
-    }
-
-    _, err = tx.Exec(context.Background(), "UPDATE room_balance SET total_saldo=$1 WHERE room_id=$2", currentSaldo, req.RoomID)
-    if err != nil {
-        http.Error(w, "Gagal update saldo: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    if err := tx.Commit(context.Background()); err != nil {
-        http.Error(w, "Gagal commit transaksi: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "status":      "success",
-        "message":     "Transaksi berhasil ditambahkan",
-        "user_id":     req.UserID,
-        "room_id":     req.RoomID,
-        "jenis":       jenis,
-        "kategori":    kategori,
-        "nominal":     req.Nominal,
-        "keterangan":  req.Keterangan,
-        "total_saldo": currentSaldo,
-        "datetime": time.Now().In(loc).Format(time.RFC3339),
-    })
+// ==========================
+// 🔹 Routing: /transaksi (GET = daftar, POST = tambah, PUT = edit, DELETE = hapus)
+// ==========================
+func (s *Server) TransaksiHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.GetTransaksi(w, r)
+	case http.MethodPost:
+		s.TambahTransaksi(w, r)
+	case http.MethodPut:
+		s.transactionAccess("transactions", false, s.EditTransaksi)(w, r)
+	case http.MethodDelete:
+		s.transactionAccess("transactions", false, s.HapusTransaksi)(w, r)
+	default:
+		http.Error(w, "Hanya GET, POST, PUT atau DELETE method yang diizinkan", http.StatusMethodNotAllowed)
+	}
 }
 
+func (s *Server) TambahTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
 
+	type TransactionRequest struct {
+		Jenis      string   `json:"jenis"`
+		Kategori   string   `json:"kategori"`    // nama kategori room, atau
+		CategoryID int      `json:"category_id"` // id kategori (diutamakan)
+		Nominal    Money    `json:"nominal"`
+		Keterangan string   `json:"keterangan"`
+		OccurredAt string   `json:"occurred_at"` // opsional, default sekarang
+		Tags       []string `json:"tags"`        // opsional
+	}
 
-func GetTransaksi(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodGet {
-        http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
-        return
-    }
-
-    // Optional: filter by query param
-    userID := r.URL.Query().Get("user_id")
-    roomID := r.URL.Query().Get("room_id")
-
-    query := "SELECT id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update FROM other_transaction"
-    var args []interface{}
-    conditions := []string{}
-
-    if userID != "" {
-        conditions = append(conditions, "user_id = $"+fmt.Sprint(len(args)+1))
-        args = append(args, userID)
-    }
-    if roomID != "" {
-        conditions = append(conditions, "room_id = $"+fmt.Sprint(len(args)+1))
-        args = append(args, roomID)
-    }
-
-    if len(conditions) > 0 {
-        query += " WHERE " + strings.Join(conditions, " AND ")
-    }
-
-    query += " ORDER BY tanggal_update DESC"
-
-    rows, err := DB.Query(context.Background(), query, args...)
-    if err != nil {
-        http.Error(w, "Gagal mengambil data: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-    defer rows.Close()
-
-    type Transaction struct {
-        ID         int       `json:"id"`
-        UserID     int       `json:"user_id"`
-        RoomID     int       `json:"room_id"`
-        Jenis      string    `json:"jenis"`
-        Kategori   string    `json:"kategori"`
-        Nominal    float64   `json:"nominal"`
-        Keterangan string    `json:"keterangan"`
-        Tanggal    time.Time `json:"tanggal_update"`
-    }
-
-    var transaksiList []Transaction
-    for rows.Next() {
-        var t Transaction
-        err := rows.Scan(&t.ID, &t.UserID, &t.RoomID, &t.Jenis, &t.Kategori, &t.Nominal, &t.Keterangan, &t.Tanggal)
-        if err != nil {
-            http.Error(w, "Gagal membaca data: "+err.Error(), http.StatusInternalServerError)
-            return
-        }
-        transaksiList = append(transaksiList, t)
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "status":      "success",
-        "total_data":  len(transaksiList),
-        "transaksi":   transaksiList,
-    })
-}
+	var req TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
 
-// ==========================
-// 🔹 API: Hapus Data user_transactions berdasarkan id
-// ==========================
-func HapusTransaksiByID(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodDelete {
-        http.Error(w, "Hanya DELETE method yang diizinkan", http.StatusMethodNotAllowed)
-        return
-    }
-
-    type DeleteRequest struct {
-        ID int `json:"id"`
-    }
-
-    var req DeleteRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "JSON tidak valid", http.StatusBadRequest)
-        return
-    }
-
-    if req.ID == 0 {
-        http.Error(w, "id wajib diisi", http.StatusBadRequest)
-        return
-    }
-
-    // 🔹 Ambil total pemasukan dan pengeluaran sebelum dihapus
-    var pemasukan, pengeluaran float64
-    var roomID int
-    err := DB.QueryRow(context.Background(),
-        `SELECT pemasukan, pengeluaran, room_id 
-         FROM user_transactions WHERE id = $1`, req.ID).
-        Scan(&pemasukan, &pengeluaran, &roomID)
-    if err != nil {
-        http.Error(w, "Data transaksi tidak ditemukan: "+err.Error(), http.StatusNotFound)
-        return
-    }
-
-    // 🔹 Hapus transaksi
-    _, err = DB.Exec(context.Background(), `DELETE FROM user_transactions WHERE id = $1`, req.ID)
-    if err != nil {
-        http.Error(w, "Gagal menghapus data: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // 🔹 Hitung penyesuaian saldo
-    saldoPenyesuaian := pemasukan - pengeluaran
-
-    // 🔹 Update saldo di room_balance
-    _, err = DB.Exec(context.Background(),
-        `UPDATE room_balance 
-         SET total_saldo = total_saldo - $1, tanggal_update = NOW()
-         WHERE room_id = $2`, saldoPenyesuaian, roomID)
-    if err != nil {
-        http.Error(w, "Gagal memperbarui total saldo: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // 🔹 Respon sukses
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "status":           "success",
-        "message":          "Transaksi berhasil dihapus",
-        "id":               req.ID,
-        "pemasukan":        pemasukan,
-        "pengeluaran":      pengeluaran,
-        "saldo_dikurangi":  saldoPenyesuaian,
-    })
-}
+	jenis := normalizeLabel(req.Jenis)
 
+	caller := currentUser(r.Context())
+	if req.Nominal <= 0 || !validJenis[jenis] || (strings.TrimSpace(req.Kategori) == "" && req.CategoryID == 0) {
+		http.Error(w, "Data tidak lengkap atau salah", http.StatusBadRequest)
+		return
+	}
+	if msg := validateAmount(req.Nominal, caller.Currency); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	tags, msg := normalizeTags(req.Tags)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "invalid_tags", msg)
+		return
+	}
 
-// ==========================
-// 🔹 API: Edit Data user_transactions berdasarkan id
-// ==========================
-func EditTransaksiUserByID(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodPut {
-        http.Error(w, "Hanya PUT method yang diizinkan", http.StatusMethodNotAllowed)
-        return
-    }
-
-    type EditRequest struct {
-        ID          int      `json:"id"`
-        Pemasukan   *float64 `json:"pemasukan,omitempty"`
-        Pengeluaran *float64 `json:"pengeluaran,omitempty"`
-    }
-
-    var req EditRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "JSON tidak valid", http.StatusBadRequest)
-        return
-    }
-
-    // 🔹 Validasi input wajib
-    if req.ID == 0 {
-        http.Error(w, "id wajib diisi", http.StatusBadRequest)
-        return
-    }
-
-    // 🔹 Pastikan hanya salah satu dari pemasukan atau pengeluaran yang diisi
-    if (req.Pemasukan == nil && req.Pengeluaran == nil) || (req.Pemasukan != nil && req.Pengeluaran != nil) {
-        http.Error(w, "Hanya boleh mengisi salah satu field: pemasukan ATAU pengeluaran", http.StatusBadRequest)
-        return
-    }
-
-    // 🔹 Ambil data lama
-    var oldPemasukan, oldPengeluaran float64
-    var roomID int
-    err := DB.QueryRow(context.Background(),
-        `SELECT pemasukan, pengeluaran, room_id FROM user_transactions WHERE id = $1`, req.ID).
-        Scan(&oldPemasukan, &oldPengeluaran, &roomID)
-    if err != nil {
-        http.Error(w, "Transaksi tidak ditemukan: "+err.Error(), http.StatusNotFound)
-        return
-    }
-
-    // 🔹 Validasi: pastikan field yang mau diubah sudah ada datanya sebelumnya
-    if req.Pemasukan != nil && oldPemasukan == 0 {
-        http.Error(w, "Tidak bisa melakukan perubahan karena data pemasukan belum ada transaksi sebelumnya", http.StatusBadRequest)
-        return
-    }
-    if req.Pengeluaran != nil && oldPengeluaran == 0 {
-        http.Error(w, "Tidak bisa melakukan perubahan karena data pengeluaran belum ada transaksi sebelumnya", http.StatusBadRequest)
-        return
-    }
-
-    // 🔹 Buat query update dinamis
-    setClauses := []string{}
-    args := []interface{}{}
-    argIdx := 1
-
-    if req.Pemasukan != nil {
-        setClauses = append(setClauses, fmt.Sprintf("pemasukan = $%d", argIdx))
-        args = append(args, *req.Pemasukan)
-        argIdx++
-    }
-    if req.Pengeluaran != nil {
-        setClauses = append(setClauses, fmt.Sprintf("pengeluaran = $%d", argIdx))
-        args = append(args, *req.Pengeluaran)
-        argIdx++
-    }
-
-    setClauses = append(setClauses, "tanggal_update = NOW()")
-    args = append(args, req.ID)
-
-    query := fmt.Sprintf(`UPDATE user_transactions SET %s WHERE id = $%d`,
-        strings.Join(setClauses, ", "), argIdx)
-
-    // 🔹 Jalankan update transaksi
-    _, err = DB.Exec(context.Background(), query, args...)
-    if err != nil {
-        http.Error(w, "Gagal memperbarui transaksi: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // 🔹 Hitung perubahan saldo
-    var newPemasukan = oldPemasukan
-    var newPengeluaran = oldPengeluaran
-    if req.Pemasukan != nil {
-        newPemasukan = *req.Pemasukan
-    }
-    if req.Pengeluaran != nil {
-        newPengeluaran = *req.Pengeluaran
-    }
-
-    perubahanSaldo := (newPemasukan - newPengeluaran) - (oldPemasukan - oldPengeluaran)
-
-    // 🔹 Update saldo di tabel room_balance
-    _, err = DB.Exec(context.Background(),
-        `UPDATE room_balance 
-         SET total_saldo = total_saldo + $1, tanggal_update = NOW()
-         WHERE room_id = $2`, perubahanSaldo, roomID)
-    if err != nil {
-        http.Error(w, "Gagal memperbarui saldo: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // 🔹 Kirim respon sukses
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "status":           "success",
-        "message":          "Transaksi berhasil diperbarui",
-        "id":               req.ID,
-        "pemasukan_lama":   oldPemasukan,
-        "pengeluaran_lama": oldPengeluaran,
-        "pemasukan_baru":   newPemasukan,
-        "pengeluaran_baru": newPengeluaran,
-        "perubahan_saldo":  perubahanSaldo,
-    })
-}
+	ctx := context.Background()
+	t := Transaction{
+		UserID:     caller.ID,
+		RoomID:     caller.RoomID,
+		Jenis:      jenis,
+		Nominal:    req.Nominal,
+		Keterangan: req.Keterangan,
+		Tags:       tags,
+	}
+	if req.OccurredAt != "" {
+		var err error
+		if t.OccurredAt, err = s.roomOccurredAt(ctx, caller.RoomID, req.OccurredAt); err != nil {
+			writeTxError(w, err)
+			return
+		}
+	}
+	var currentSaldo Money
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Kategori harus kategori aktif milik room
+		c, err := resolveKategori(ctx, tx, caller.RoomID, req.CategoryID, req.Kategori)
+		if err != nil {
+			return err
+		}
+		t.Kategori, t.CategoryID = c.Name, c.ID
 
+		currentSaldo, err = createTransaction(ctx, tx, &t, true)
+		return err
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
 
-func EditOtherTransaksiByID(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodPut {
-        http.Error(w, "Hanya PUT method yang diizinkan", http.StatusMethodNotAllowed)
-        return
-    }
-
-    type EditRequest struct {
-        ID      int     `json:"id"`
-        Jenis   string  `json:"jenis"`
-        Nominal float64 `json:"nominal"`
-    }
-
-    var req EditRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "JSON tidak valid", http.StatusBadRequest)
-        return
-    }
-
-    if req.ID == 0 {
-        http.Error(w, "id wajib diisi", http.StatusBadRequest)
-        return
-    }
-    if req.Jenis == "" {
-        http.Error(w, "jenis wajib diisi", http.StatusBadRequest)
-        return
-    }
-
-    // 🔹 Ambil data transaksi lama
-    var oldNominal float64
-    var oldJenis, oldKategori string
-    var userID, roomID int
-    err := DB.QueryRow(context.Background(),
-        `SELECT user_id, room_id, jenis, kategori, nominal 
-         FROM other_transaction WHERE id = $1`, req.ID).
-        Scan(&userID, &roomID, &oldJenis, &oldKategori, &oldNominal)
-    if err != nil {
-        http.Error(w, "Transaksi tidak ditemukan: "+err.Error(), http.StatusNotFound)
-        return
-    }
-
-    // 🔹 Ambil total saldo sekarang
-    var totalSaldo float64
-    err = DB.QueryRow(context.Background(),
-        `SELECT total_saldo FROM room_balance WHERE room_id = $1`, roomID).
-        Scan(&totalSaldo)
-    if err != nil {
-        http.Error(w, "Gagal mengambil saldo: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    var perubahanSaldo float64
-
-    // 🔹 CASE 1: Jenis transaksi tidak berubah
-    if oldJenis == req.Jenis {
-        if req.Jenis == "Pemasukan" {
-            // Tambah/kurangi sesuai selisih nominal
-            perubahanSaldo = req.Nominal - oldNominal
-        } else if req.Jenis == "Pengeluaran" {
-            // Jika nominal pengeluaran naik → saldo turun, jika turun → saldo naik
-            perubahanSaldo = oldNominal - req.Nominal
-        }
-
-    } else {
-        // 🔹 CASE 2: Jenis transaksi berubah
-        if oldJenis == "Pemasukan" && req.Jenis == "Pengeluaran" {
-            // Dulu nambah saldo, sekarang malah ngurangin saldo
-            perubahanSaldo = -(oldNominal + req.Nominal)
-        } else if oldJenis == "Pengeluaran" && req.Jenis == "Pemasukan" {
-            // Dulu ngurangin saldo, sekarang nambah saldo
-            perubahanSaldo = oldNominal + req.Nominal
-        }
-    }
-
-    // 🔹 Update data transaksi
-    _, err = DB.Exec(context.Background(),
-        `UPDATE other_transaction 
-         SET jenis=$1, nominal=$2, tanggal_update=NOW()
-         WHERE id=$3`,
-        req.Jenis, req.Nominal, req.ID)
-    if err != nil {
-        http.Error(w, "Gagal memperbarui transaksi: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // 🔹 Update saldo room_balance
-    _, err = DB.Exec(context.Background(),
-        `UPDATE room_balance 
-         SET total_saldo = total_saldo + $1, tanggal_update = NOW()
-         WHERE room_id = $2`,
-        perubahanSaldo, roomID)
-    if err != nil {
-        http.Error(w, "Gagal memperbarui saldo: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    // 🔹 Kirim respon sukses
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "status":          "success",
-        "message":         "Transaksi berhasil diperbarui",
-        "id":              req.ID,
-        "user_id":         userID,
-        "room_id":         roomID,
-        "jenis_lama":      oldJenis,
-        "kategori_lama":   oldKategori,
-        "nominal_lama":    oldNominal,
-        "jenis_baru":      req.Jenis,
-        "nominal_baru":    req.Nominal,
-        "perubahan_saldo": perubahanSaldo,
-    })
+	setTransactionETag(w, t)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "success",
+		"message":     "Transaksi berhasil ditambahkan",
+		"id":          t.ID,
+		"version":     t.Version,
+		"user_id":     caller.ID,
+		"room_id":     caller.RoomID,
+		"jenis":       jenis,
+		"kategori":    t.Kategori,
+		"category_id": t.CategoryID,
+		"nominal":     req.Nominal,
+		"keterangan":  req.Keterangan,
+		"tags":        t.Tags,
+		"occurred_at": t.OccurredAt,
+		"created_at":  t.CreatedAt,
+		"total_saldo": currentSaldo,
+		"datetime":    time.Now().In(loc).Format(time.RFC3339),
+	})
 }
 
+func (s *Server) GetTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Room selalu diambil dari token, user_id & jenis hanya filter opsional di dalam room
+	caller := currentUser(r.Context())
 
+	// 🔹 ?id=N: satu transaksi beserta ETag untuk edit berikutnya
+	if v := r.URL.Query().Get("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			http.Error(w, "id tidak valid", http.StatusBadRequest)
+			return
+		}
+		t, err := s.store.Transactions().Get(context.Background(), id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			http.Error(w, "Gagal mengambil data: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err != nil || t.RoomID != caller.RoomID {
+			writeError(w, http.StatusNotFound, "not_found", "Transaksi tidak ditemukan")
+			return
+		}
+		setTransactionETag(w, t)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"transaksi": t,
+		})
+		return
+	}
 
+	ctx := context.Background()
+	filter, err := s.readLedgerQuery(ctx, r, caller.RoomID)
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
 
+	transaksiList, total, next, err := s.listLedgerPage(ctx, filter)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "success",
+		"total_data":  len(transaksiList),
+		"total":       total,
+		"next_cursor": nullableCursor(next),
+		"transaksi":   transaksiList,
+	})
+}
 
 // ==========================
-// 🔹 API: Ambil Data other_transaction berdasarkan room_id
+// 🔹 API: Edit transaksi berdasarkan id
 // ==========================
-func GetOtherTransactionsByRoom(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+//
+// Dipakai PUT /transaksi dan /edit-transaksi-lainnya; hanya field yang
+// dikirim yang berubah.
+func (s *Server) EditTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Hanya PUT method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type EditRequest struct {
+		ID         int       `json:"id"`
+		Version    *int      `json:"version,omitempty"` // atau header If-Match
+		Jenis      *string   `json:"jenis,omitempty"`
+		Kategori   *string   `json:"kategori,omitempty"`
+		CategoryID *int      `json:"category_id,omitempty"`
+		Nominal    *Money    `json:"nominal,omitempty"`
+		Keterangan *string   `json:"keterangan,omitempty"`
+		OccurredAt *string   `json:"occurred_at,omitempty"`
+		Tags       *[]string `json:"tags,omitempty"` // mengganti seluruh tag
+	}
+
+	var req EditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
 		return
 	}
 
-	roomID := r.URL.Query().Get("room_id")
-	if roomID == "" {
-		http.Error(w, "room_id wajib diisi", http.StatusBadRequest)
+	if req.ID == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
+	}
+	if req.Jenis != nil {
+		*req.Jenis = normalizeLabel(*req.Jenis)
+		if !validJenis[*req.Jenis] {
+			http.Error(w, "jenis wajib diisi (Pemasukan/Pengeluaran)", http.StatusBadRequest)
+			return
+		}
+	}
+	caller := currentUser(r.Context())
+	if req.Nominal != nil {
+		if msg := validateAmount(*req.Nominal, caller.Currency); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+	var tags []string
+	if req.Tags != nil {
+		var msg string
+		if tags, msg = normalizeTags(*req.Tags); msg != "" {
+			writeError(w, http.StatusBadRequest, "invalid_tags", msg)
+			return
+		}
+	}
+	precond, ok := readPrecondition(w, r, req.Version)
+	if !ok {
 		return
 	}
 
-	query := `SELECT id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update 
-			  FROM other_transaction 
-			  WHERE room_id = $1 
-			  ORDER BY tanggal_update DESC`
-	rows, err := DB.Query(context.Background(), query, roomID)
+	ctx := context.Background()
+	var occurredAt time.Time
+	if req.OccurredAt != nil {
+		var err error
+		if occurredAt, err = s.roomOccurredAt(ctx, caller.RoomID, *req.OccurredAt); err != nil {
+			writeTxError(w, err)
+			return
+		}
+	}
+
+	var (
+		old, updated               Transaction
+		perubahanSaldo, totalSaldo Money
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Ambil (dan kunci) data transaksi lama
+		var err error
+		old, err = tx.Transactions().Lock(ctx, req.ID)
+		if err != nil {
+			return clientError(http.StatusNotFound, "", "Transaksi tidak ditemukan: "+err.Error())
+		}
+
+		// 🔹 Tolak jika client mengedit dari versi yang sudah usang
+		if err := precond.check(old); err != nil {
+			return err
+		}
+
+		updated = old
+		if req.Jenis != nil {
+			updated.Jenis = *req.Jenis
+		}
+		if req.Kategori != nil || req.CategoryID != nil {
+			var name string
+			var id int
+			if req.Kategori != nil {
+				name = *req.Kategori
+			}
+			if req.CategoryID != nil {
+				id = *req.CategoryID
+			}
+			c, err := resolveKategori(ctx, tx, old.RoomID, id, name)
+			if err != nil {
+				return err
+			}
+			updated.Kategori, updated.CategoryID = c.Name, c.ID
+		}
+		if req.Nominal != nil {
+			updated.Nominal = *req.Nominal
+		}
+		if req.Keterangan != nil {
+			updated.Keterangan = *req.Keterangan
+		}
+		if req.OccurredAt != nil {
+			updated.OccurredAt = occurredAt
+		}
+
+		// 🔹 Selisih saldo = efek baru - efek lama (berlaku juga saat jenis berubah)
+		perubahanSaldo = updated.Delta() - old.Delta()
+
+		// 🔹 Update data transaksi
+		if err := tx.Transactions().Update(ctx, &updated); err != nil {
+			return fmt.Errorf("Gagal memperbarui transaksi: %w", err)
+		}
+		if req.Tags != nil {
+			updated.Tags = tags
+			if err := setTransactionTags(ctx, tx, &updated); err != nil {
+				return err
+			}
+		}
+
+		// 🔹 Update saldo room_balance
+		totalSaldo, err = addRoomBalance(ctx, tx, old.RoomID, perubahanSaldo)
+		if err != nil {
+			return fmt.Errorf("Gagal memperbarui saldo: %w", err)
+		}
+		if err := guardNegativeBalance(ctx, tx, old.RoomID, totalSaldo); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, caller.ID, auditUpdate, &old, &updated, perubahanSaldo)
+	})
 	if err != nil {
-		http.Error(w, "Gagal mengambil data: "+err.Error(), http.StatusInternalServerError)
+		writeTxError(w, err)
 		return
 	}
-	defer rows.Close()
 
-	type Transaction struct {
-		ID         int       `json:"id"`
-		UserID     int       `json:"user_id"`
-		RoomID     int       `json:"room_id"`
-		Jenis      string    `json:"jenis"`
-		Kategori   string    `json:"kategori"`
-		Nominal    float64   `json:"nominal"`
-		Keterangan string    `json:"keterangan"`
-		Tanggal    time.Time `json:"tanggal_update"`
-	}
-
-	var transaksiList []Transaction
-	for rows.Next() {
-		var t Transaction
-		if err := rows.Scan(&t.ID, &t.UserID, &t.RoomID, &t.Jenis, &t.Kategori, &t.Nominal, &t.Keterangan, &t.Tanggal); err != nil {
-			http.Error(w, "Gagal membaca data: "+err.Error(), http.StatusInternalServerError)
+	// 🔹 Kirim respon sukses
+	setTransactionETag(w, updated)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":          "success",
+		"message":         "Transaksi berhasil diperbarui",
+		"id":              req.ID,
+		"version":         updated.Version,
+		"user_id":         old.UserID,
+		"room_id":         old.RoomID,
+		"jenis_lama":      old.Jenis,
+		"kategori_lama":   old.Kategori,
+		"nominal_lama":    old.Nominal,
+		"jenis_baru":      updated.Jenis,
+		"kategori_baru":   updated.Kategori,
+		"nominal_baru":    updated.Nominal,
+		"keterangan":      updated.Keterangan,
+		"tags":            updated.Tags,
+		"occurred_at":     updated.OccurredAt,
+		"updated_at":      updated.UpdatedAt,
+		"perubahan_saldo": perubahanSaldo,
+		"total_saldo":     totalSaldo,
+		"transaksi":       updated,
+	})
+}
+
+// ==========================
+// 🔹 API: Hapus transaksi berdasarkan id
+// ==========================
+//
+// Dipakai DELETE /transaksi dan /hapus-transaksi-lainnya. Efek transaksi
+// terhadap saldo dibalik sesuai jenis: menghapus pemasukan mengurangi
+// saldo, menghapus pengeluaran menambah saldo. Transaksi masuk tempat
+// sampah dan bisa dipulihkan lewat /transaksi/pulihkan.
+func (s *Server) HapusTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Hanya DELETE method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type DeleteRequest struct {
+		ID int `json:"id"`
+	}
+
+	// id boleh dikirim lewat query string (?id=) atau body
+	var req DeleteRequest
+	req.ID, _ = strconv.Atoi(r.URL.Query().Get("id"))
+	if req.ID == 0 {
+		if err := peekJSONBody(r, &req); err != nil {
+			http.Error(w, "JSON tidak valid", http.StatusBadRequest)
 			return
 		}
-		transaksiList = append(transaksiList, t)
 	}
 
+	if req.ID == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+	var (
+		old                        Transaction
+		perubahanSaldo, totalSaldo Money
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Ambil (dan kunci) transaksi sebelum dihapus
+		var err error
+		old, err = tx.Transactions().Lock(ctx, req.ID)
+		if err != nil {
+			return clientError(http.StatusNotFound, "", "Transaksi tidak ditemukan: "+err.Error())
+		}
+
+		// 🔹 Pindahkan ke tempat sampah (bisa dipulihkan)
+		before := old
+		if err := tx.Transactions().SoftDelete(ctx, &old, caller.ID); err != nil {
+			return fmt.Errorf("Gagal menghapus data: %w", err)
+		}
+		if err := recordAudit(ctx, tx, caller.ID, auditDelete, &before, &old, -old.Delta()); err != nil {
+			return err
+		}
+
+		// 🔹 Balik efek transaksi terhadap saldo room
+		perubahanSaldo = -old.Delta()
+		totalSaldo, err = addRoomBalance(ctx, tx, old.RoomID, perubahanSaldo)
+		if err != nil {
+			return fmt.Errorf("Gagal memperbarui total saldo: %w", err)
+		}
+		return guardNegativeBalance(ctx, tx, old.RoomID, totalSaldo)
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	// 🔹 Respon sukses
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":     "success",
-		"total_data": len(transaksiList),
-		"data":       transaksiList,
+		"status":          "success",
+		"message":         "Transaksi berhasil dihapus",
+		"id":              old.ID,
+		"user_id":         old.UserID,
+		"room_id":         old.RoomID,
+		"jenis":           old.Jenis,
+		"kategori":        old.Kategori,
+		"nominal":         old.Nominal,
+		"perubahan_saldo": perubahanSaldo,
+		"total_saldo":     totalSaldo,
+		"deleted_at":      old.DeletedAt,
+		"purge_at":        old.DeletedAt.Add(cfg.TrashRetention.Duration),
 	})
 }
 
-func GetAllTransactionsByRoom(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+// lockLegacyUserTransaction mengunci transaksi berdasarkan id lama
+// user_transactions.
+func lockLegacyUserTransaction(ctx context.Context, tx Store, legacyID int) (Transaction, error) {
+	t, err := tx.Transactions().GetByLegacyUserID(ctx, legacyID)
+	if err != nil {
+		return t, err
+	}
+	return tx.Transactions().Lock(ctx, t.ID)
+}
+
+// ==========================
+// 🔹 API: Hapus Data user_transactions berdasarkan id
+// ==========================
+func (s *Server) HapusTransaksiByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Hanya DELETE method yang diizinkan", http.StatusMethodNotAllowed)
 		return
 	}
 
-	roomID := r.URL.Query().Get("room_id")
-	if roomID == "" {
-		http.Error(w, "room_id wajib diisi", http.StatusBadRequest)
+	type DeleteRequest struct {
+		ID int `json:"id"`
+	}
+
+	var req DeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
 		return
 	}
 
-	type Transaction struct {
-		ID          int       `json:"id"`
-		UserID      int       `json:"user_id"`
-		RoomID      int       `json:"room_id"`
-		Pemasukan   float64   `json:"pemasukan,omitempty"`
-		Pengeluaran float64   `json:"pengeluaran,omitempty"`
-		Jenis       string    `json:"jenis,omitempty"`
-		Kategori    string    `json:"kategori,omitempty"`
-		Nominal     float64   `json:"nominal,omitempty"`
-		Keterangan  string    `json:"keterangan,omitempty"`
-		Tanggal     time.Time `json:"tanggal_update"`
-		Source      string    `json:"source"` // new field: "user" or "other"
+	if req.ID == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
 	}
 
-	var transaksiList []Transaction
+	caller := currentUser(r.Context())
+	ctx := context.Background()
+	var (
+		old              Transaction
+		saldoPenyesuaian Money
+		totalSaldo       Money
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Ambil (dan kunci) transaksi sebelum dihapus
+		var err error
+		old, err = lockLegacyUserTransaction(ctx, tx, req.ID)
+		if err != nil {
+			return clientError(http.StatusNotFound, "", "Data transaksi tidak ditemukan: "+err.Error())
+		}
+
+		// 🔹 Pindahkan ke tempat sampah (bisa dipulihkan)
+		before := old
+		if err := tx.Transactions().SoftDelete(ctx, &old, caller.ID); err != nil {
+			return fmt.Errorf("Gagal menghapus data: %w", err)
+		}
+		if err := recordAudit(ctx, tx, caller.ID, auditDelete, &before, &old, -old.Delta()); err != nil {
+			return err
+		}
+
+		// 🔹 Hitung penyesuaian saldo
+		saldoPenyesuaian = old.Delta()
 
-	// --- Query user_transactions ---
-	rows, err := DB.Query(context.Background(), `
-		SELECT id, user_id, room_id, pemasukan, pengeluaran, tanggal_update 
-		FROM user_transactions WHERE room_id=$1 ORDER BY tanggal_update DESC
-	`, roomID)
+		// 🔹 Update saldo di room_balance
+		totalSaldo, err = addRoomBalance(ctx, tx, old.RoomID, -saldoPenyesuaian)
+		if err != nil {
+			return fmt.Errorf("Gagal memperbarui total saldo: %w", err)
+		}
+		return guardNegativeBalance(ctx, tx, old.RoomID, totalSaldo)
+	})
 	if err != nil {
-		http.Error(w, "Gagal mengambil user_transactions: "+err.Error(), http.StatusInternalServerError)
+		writeTxError(w, err)
+		return
+	}
+
+	pemasukan, pengeluaran := legacyColumns(old)
+
+	// 🔹 Respon sukses
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":          "success",
+		"message":         "Transaksi berhasil dihapus",
+		"id":              req.ID,
+		"transaction_id":  old.ID,
+		"pemasukan":       pemasukan,
+		"pengeluaran":     pengeluaran,
+		"saldo_dikurangi": saldoPenyesuaian,
+		"deleted_at":      old.DeletedAt,
+		"total_saldo":     totalSaldo,
+	})
+}
+
+// ==========================
+// 🔹 API: Edit Data user_transactions berdasarkan id
+// ==========================
+func (s *Server) EditTransaksiUserByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Hanya PUT method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type EditRequest struct {
+		ID          int    `json:"id"`
+		Version     *int   `json:"version,omitempty"` // atau header If-Match
+		Pemasukan   *Money `json:"pemasukan,omitempty"`
+		Pengeluaran *Money `json:"pengeluaran,omitempty"`
+	}
+
+	var req EditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var t Transaction
-		if err := rows.Scan(&t.ID, &t.UserID, &t.RoomID, &t.Pemasukan, &t.Pengeluaran, &t.Tanggal); err != nil {
-			http.Error(w, "Gagal membaca user_transactions: "+err.Error(), http.StatusInternalServerError)
+	// 🔹 Validasi input wajib
+	if req.ID == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	// 🔹 Pastikan hanya salah satu dari pemasukan atau pengeluaran yang diisi
+	if (req.Pemasukan == nil && req.Pengeluaran == nil) || (req.Pemasukan != nil && req.Pengeluaran != nil) {
+		http.Error(w, "Hanya boleh mengisi salah satu field: pemasukan ATAU pengeluaran", http.StatusBadRequest)
+		return
+	}
+	caller := currentUser(r.Context())
+	for _, m := range []*Money{req.Pemasukan, req.Pengeluaran} {
+		if m == nil {
+			continue
+		}
+		if msg := validateAmount(*m, caller.Currency); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
 			return
 		}
-		t.Source = "user"
-		transaksiList = append(transaksiList, t)
+	}
+	precond, ok := readPrecondition(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	var (
+		t                            Transaction
+		oldPemasukan, oldPengeluaran Money
+		newPemasukan, newPengeluaran Money
+		perubahanSaldo, totalSaldo   Money
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Ambil (dan kunci) data lama
+		var err error
+		t, err = lockLegacyUserTransaction(ctx, tx, req.ID)
+		if err != nil {
+			return clientError(http.StatusNotFound, "", "Transaksi tidak ditemukan: "+err.Error())
+		}
+
+		// 🔹 Tolak jika client mengedit dari versi yang sudah usang
+		if err := precond.check(t); err != nil {
+			return err
+		}
+		before := t
+		oldPemasukan, oldPengeluaran = legacyColumns(t)
+		oldDelta := t.Delta()
+
+		// 🔹 Validasi: pastikan field yang mau diubah sudah ada datanya sebelumnya
+		if req.Pemasukan != nil && oldPemasukan == 0 {
+			return clientError(http.StatusBadRequest, "", "Tidak bisa melakukan perubahan karena data pemasukan belum ada transaksi sebelumnya")
+		}
+		if req.Pengeluaran != nil && oldPengeluaran == 0 {
+			return clientError(http.StatusBadRequest, "", "Tidak bisa melakukan perubahan karena data pengeluaran belum ada transaksi sebelumnya")
+		}
+
+		// 🔹 Hanya nominal yang berubah, jenis tetap
+		if req.Pemasukan != nil {
+			t.Nominal = *req.Pemasukan
+		} else {
+			t.Nominal = *req.Pengeluaran
+		}
+		newPemasukan, newPengeluaran = legacyColumns(t)
+
+		// 🔹 Jalankan update transaksi
+		if err := tx.Transactions().Update(ctx, &t); err != nil {
+			return fmt.Errorf("Gagal memperbarui transaksi: %w", err)
+		}
+
+		// 🔹 Hitung perubahan saldo
+		perubahanSaldo = t.Delta() - oldDelta
+
+		// 🔹 Update saldo di tabel room_balance
+		totalSaldo, err = addRoomBalance(ctx, tx, t.RoomID, perubahanSaldo)
+		if err != nil {
+			return fmt.Errorf("Gagal memperbarui saldo: %w", err)
+		}
+		if err := guardNegativeBalance(ctx, tx, t.RoomID, totalSaldo); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, caller.ID, auditUpdate, &before, &t, perubahanSaldo)
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	// 🔹 Kirim respon sukses
+	setTransactionETag(w, t)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":           "success",
+		"message":          "Transaksi berhasil diperbarui",
+		"id":               req.ID,
+		"version":          t.Version,
+		"pemasukan_lama":   oldPemasukan,
+		"pengeluaran_lama": oldPengeluaran,
+		"pemasukan_baru":   newPemasukan,
+		"pengeluaran_baru": newPengeluaran,
+		"perubahan_saldo":  perubahanSaldo,
+		"total_saldo":      totalSaldo,
+	})
+}
+
+// ==========================
+// 🔹 API: Seluruh transaksi room (format lama dengan field source)
+// ==========================
+func (s *Server) GetAllTransactionsByRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// --- Query other_transaction ---
-	rows2, err := DB.Query(context.Background(), `
-		SELECT id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update
-		FROM other_transaction WHERE room_id=$1 ORDER BY tanggal_update DESC
-	`, roomID)
+	roomID := currentUser(r.Context()).RoomID
+
+	type LegacyTransaction struct {
+		ID            int       `json:"id"`
+		TransactionID int       `json:"transaction_id"`
+		UserID        int       `json:"user_id"`
+		RoomID        int       `json:"room_id"`
+		Pemasukan     Money     `json:"pemasukan,omitempty"`
+		Pengeluaran   Money     `json:"pengeluaran,omitempty"`
+		Jenis         string    `json:"jenis,omitempty"`
+		Kategori      string    `json:"kategori,omitempty"`
+		Nominal       Money     `json:"nominal,omitempty"`
+		Keterangan    string    `json:"keterangan,omitempty"`
+		Tags          []string  `json:"tags"`
+		OccurredAt    time.Time `json:"occurred_at"`
+		Tanggal       time.Time `json:"tanggal_update"` // updated_at, nama lama
+		Version       int       `json:"version"`
+		Source        string    `json:"source"` // "user" atau "other"
+	}
+
+	ctx := context.Background()
+	filter, err := s.readLedgerQuery(ctx, r, roomID)
 	if err != nil {
-		http.Error(w, "Gagal mengambil other_transaction: "+err.Error(), http.StatusInternalServerError)
+		writeTxError(w, err)
 		return
 	}
-	defer rows2.Close()
 
-	for rows2.Next() {
-		var t Transaction
-		if err := rows2.Scan(&t.ID, &t.UserID, &t.RoomID, &t.Jenis, &t.Kategori, &t.Nominal, &t.Keterangan, &t.Tanggal); err != nil {
-			http.Error(w, "Gagal membaca other_transaction: "+err.Error(), http.StatusInternalServerError)
-			return
+	rows, total, next, err := s.listLedgerPage(ctx, filter)
+	if err != nil {
+		http.Error(w, "Gagal mengambil transaksi: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	transaksiList := make([]LegacyTransaction, 0, len(rows))
+	for _, t := range rows {
+		id, _ := legacyRef(t)
+		item := LegacyTransaction{
+			ID:            id,
+			TransactionID: t.ID,
+			UserID:        t.UserID,
+			RoomID:        t.RoomID,
+			Tags:          t.Tags,
+			OccurredAt:    t.OccurredAt,
+			Tanggal:       t.UpdatedAt,
+			Version:       t.Version,
+			Source:        "other",
 		}
-		t.Source = "other"
-		transaksiList = append(transaksiList, t)
+		if t.LegacyUserID != nil {
+			item.Pemasukan, item.Pengeluaran = legacyColumns(t)
+			item.Source = "user"
+		} else {
+			item.Jenis, item.Kategori, item.Nominal, item.Keterangan = t.Jenis, t.Kategori, t.Nominal, t.Keterangan
+		}
+		transaksiList = append(transaksiList, item)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":     "success",
-		"total_data": len(transaksiList),
-		"data":       transaksiList,
+		"status":      "success",
+		"total_data":  len(transaksiList),
+		"total":       total,
+		"next_cursor": nullableCursor(next),
+		"data":        transaksiList,
 	})
 }
-
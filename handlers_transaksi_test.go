@@ -0,0 +1,423 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLegacyPemasukanPengeluaran(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+
+	ts.expect(http.StatusOK, "POST", "/pemasukan", u.Token, map[string]interface{}{"amount": 100000})
+	out := ts.expect(http.StatusOK, "POST", "/pengeluaran", u.Token, map[string]interface{}{"amount": 30000})
+	if num(out["total_saldo"]) != 70000 {
+		t.Fatalf("total_saldo %v, mau 70000", out["total_saldo"])
+	}
+	if rec := ts.request("POST", "/pemasukan", u.Token, map[string]interface{}{"amount": -5}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("amount negatif: status %d", rec.Code)
+	}
+
+	// 🔹 Id lama dipakai endpoint edit/hapus lama
+	list := ts.expect(http.StatusOK, "GET", "/seluruh-transaksi", u.Token, nil)
+	data := list["data"].([]interface{})
+	if len(data) != 2 {
+		t.Fatalf("seluruh-transaksi: %v", list)
+	}
+	var legacyID, version float64
+	for _, d := range data {
+		item := d.(map[string]interface{})
+		if item["source"] != "user" {
+			t.Fatalf("source %v, mau user", item["source"])
+		}
+		if num(item["pengeluaran"]) == 30000 {
+			legacyID, version = num(item["id"]), num(item["version"])
+		}
+	}
+
+	ts.expect(http.StatusOK, "PUT", "/edit-transaksi-user", u.Token, map[string]interface{}{
+		"id": legacyID, "version": version, "pengeluaran": 40000,
+	})
+	if got := ts.saldo(u); got != 60000 {
+		t.Fatalf("saldo setelah edit %v, mau 60000", got)
+	}
+	ts.expect(http.StatusOK, "DELETE", "/hapus-transaksi-user", u.Token, map[string]interface{}{"id": legacyID})
+	if got := ts.saldo(u); got != 100000 {
+		t.Fatalf("saldo setelah hapus %v, mau 100000", got)
+	}
+}
+
+func TestTambahTransaksiValidasi(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 100000})
+
+	cases := []struct {
+		name   string
+		body   interface{}
+		status int
+	}{
+		{"JSON rusak", "{", http.StatusBadRequest},
+		{"nominal nol", map[string]interface{}{"jenis": "Pemasukan", "kategori": "Makanan", "nominal": 0}, http.StatusBadRequest},
+		{"jenis tidak dikenal", map[string]interface{}{"jenis": "Hibah", "kategori": "Makanan", "nominal": 10}, http.StatusBadRequest},
+		{"tanpa kategori", map[string]interface{}{"jenis": "Pemasukan", "nominal": 10}, http.StatusBadRequest},
+		{"kategori bukan milik room", map[string]interface{}{"jenis": "Pemasukan", "kategori": "Tidak Ada", "nominal": 10}, http.StatusBadRequest},
+		{"occurred_at rusak", map[string]interface{}{"jenis": "Pemasukan", "kategori": "Makanan", "nominal": 10, "occurred_at": "kemarin"}, http.StatusBadRequest},
+		{"pecahan rupiah", map[string]interface{}{"jenis": "Pemasukan", "kategori": "Makanan", "nominal": 12500.5}, http.StatusBadRequest},
+		{"saldo tidak cukup", map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 200000}, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec := ts.request("POST", "/transaksi-lainnya", u.Token, c.body); rec.Code != c.status {
+				t.Fatalf("status %d, mau %d: %s", rec.Code, c.status, rec.Body.String())
+			}
+		})
+	}
+
+	rec := ts.request("POST", "/transaksi", u.Token, map[string]interface{}{
+		"jenis": "pengeluaran", "kategori": "makanan", "nominal": 12500, "tags": []string{"Kopi", "kopi", "kantor"},
+	})
+	out := decodeJSON(t, rec)
+	if rec.Code != http.StatusOK || out["kategori"] != "Makanan" || out["jenis"] != "Pengeluaran" || num(out["total_saldo"]) != 87500 {
+		t.Fatalf("tambah transaksi: %d %v", rec.Code, out)
+	}
+	if etag := rec.Header().Get("ETag"); etag != fmt.Sprintf(`"%d-1"`, int(num(out["id"]))) {
+		t.Fatalf("ETag %q", etag)
+	}
+	if tags := out["tags"].([]interface{}); len(tags) != 2 {
+		t.Fatalf("tag duplikat tidak digabung: %v", tags)
+	}
+}
+
+func TestHapusTransaksi(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", map[string]interface{}{"forbid_negative_balance": true})
+	gaji := ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 100000})
+	makan := ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 30000})
+
+	// 🔹 Menghapus pemasukan akan membuat saldo negatif: ditolak dan tidak ada yang berubah
+	rec := ts.request("DELETE", fmt.Sprintf("/transaksi?id=%d", gaji), u.Token, nil)
+	if rec.Code != http.StatusConflict || errorCode(t, rec) != "negative_balance" {
+		t.Fatalf("hapus pemasukan: status %d %s", rec.Code, rec.Body.String())
+	}
+	if got := ts.saldo(u); got != 70000 {
+		t.Fatalf("saldo setelah penolakan %v, mau 70000", got)
+	}
+
+	// 🔹 Menghapus pengeluaran membalik efeknya terhadap saldo
+	out := ts.expect(http.StatusOK, "DELETE", "/hapus-transaksi-lainnya", u.Token, map[string]interface{}{"id": makan})
+	if num(out["perubahan_saldo"]) != 30000 || num(out["total_saldo"]) != 100000 {
+		t.Fatalf("hapus pengeluaran: %v", out)
+	}
+	if rec := ts.request("DELETE", "/hapus-transaksi-lainnya", u.Token, map[string]interface{}{"id": makan}); rec.Code != http.StatusNotFound {
+		t.Fatalf("hapus dua kali: status %d", rec.Code)
+	}
+
+	// 🔹 Tanpa aturan room, saldo boleh negatif
+	ts.expect(http.StatusOK, "PATCH", "/rooms", u.Token, map[string]interface{}{"forbid_negative_balance": false})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 40000})
+	ts.expect(http.StatusOK, "DELETE", "/transaksi", u.Token, map[string]interface{}{"id": gaji})
+	if got := ts.saldo(u); got != -40000 {
+		t.Fatalf("saldo %v, mau -40000", got)
+	}
+}
+
+func TestOccurredAtZonaWaktuRoom(t *testing.T) {
+	ts := newTestServer(t)
+	if rec := ts.request("POST", "/rooms", ts.register("Bela").Token, map[string]interface{}{"room_name": "R", "timezone": "Asia/Atlantis"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("timezone tidak dikenal: status %d", rec.Code)
+	}
+	u := ts.newRoomUser("Andi", map[string]interface{}{"timezone": "Asia/Makassar"})
+
+	// 🔹 occurred_at tanpa offset dibaca di zona waktu room (WITA, UTC+8)
+	out := ts.expect(http.StatusOK, "POST", "/transaksi", u.Token, map[string]interface{}{
+		"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 50000, "occurred_at": "2026-03-01 08:00",
+	})
+	got, err := time.Parse(time.RFC3339, out["occurred_at"].(string))
+	if err != nil || !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("occurred_at %v", out["occurred_at"])
+	}
+
+	// 🔹 Edit boleh memindahkan tanggal transaksi
+	out = ts.expect(http.StatusOK, "PUT", "/transaksi", u.Token, map[string]interface{}{
+		"id": out["id"], "version": 1, "occurred_at": "2026-02-27",
+	})
+	got, _ = time.Parse(time.RFC3339, out["occurred_at"].(string))
+	if !got.Equal(time.Date(2026, 2, 26, 16, 0, 0, 0, time.UTC)) {
+		t.Fatalf("occurred_at setelah edit %v", out["occurred_at"])
+	}
+}
+
+// ==========================
+// 🔹 ETag / If-Match
+// ==========================
+
+func TestEditTransaksiVersi(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 100000})
+	id := ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 20000})
+
+	rec := ts.request("GET", fmt.Sprintf("/get-transaksi?id=%d", id), u.Token, nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag != fmt.Sprintf(`"%d-1"`, id) {
+		t.Fatalf("GET ?id=: %d ETag %q", rec.Code, etag)
+	}
+
+	edit := map[string]interface{}{"id": id, "nominal": 25000}
+	if rec := ts.request("PUT", "/transaksi", u.Token, edit); rec.Code != http.StatusPreconditionRequired || errorCode(t, rec) != "version_required" {
+		t.Fatalf("tanpa versi: status %d %s", rec.Code, rec.Body.String())
+	}
+
+	out := ts.expect(http.StatusOK, "PUT", "/transaksi", u.Token, edit, "If-Match", etag)
+	if num(out["version"]) != 2 || num(out["total_saldo"]) != 75000 {
+		t.Fatalf("edit dengan If-Match: %v", out)
+	}
+
+	// 🔹 ETag lama ditolak dan respon membawa data terkini
+	rec = ts.request("PUT", "/transaksi", u.Token, edit, "If-Match", etag)
+	if rec.Code != http.StatusPreconditionFailed || errorCode(t, rec) != "precondition_failed" {
+		t.Fatalf("If-Match basi: status %d %s", rec.Code, rec.Body.String())
+	}
+	data := decodeJSON(t, rec)
+	if data["etag"] != fmt.Sprintf(`"%d-2"`, id) || num(data["current"].(map[string]interface{})["nominal"]) != 25000 {
+		t.Fatalf("data konflik: %v", data)
+	}
+
+	edit["version"] = 1
+	if rec := ts.request("PUT", "/edit-transaksi-lainnya", u.Token, edit); rec.Code != http.StatusConflict || errorCode(t, rec) != "version_conflict" {
+		t.Fatalf("version basi: status %d %s", rec.Code, rec.Body.String())
+	}
+	edit["version"] = 2
+	ts.expect(http.StatusOK, "PUT", "/edit-transaksi-lainnya", u.Token, edit)
+	if got := ts.saldo(u); got != 75000 {
+		t.Fatalf("saldo %v, mau 75000", got)
+	}
+}
+
+// ==========================
+// 🔹 Tempat sampah, riwayat & aktivitas
+// ==========================
+
+func TestSoftDeleteRestore(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 100000})
+	id := ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 20000})
+
+	out := ts.expect(http.StatusOK, "DELETE", "/transaksi", u.Token, map[string]interface{}{"id": id})
+	if num(out["total_saldo"]) != 100000 || out["purge_at"] == nil {
+		t.Fatalf("hapus: %v", out)
+	}
+	if rec := ts.request("GET", fmt.Sprintf("/get-transaksi?id=%d", id), u.Token, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("transaksi terhapus masih bisa dibaca: status %d", rec.Code)
+	}
+	if rec := ts.request("DELETE", "/hapus-transaksi-lainnya", u.Token, map[string]interface{}{"id": id}); rec.Code != http.StatusNotFound {
+		t.Fatalf("hapus dua kali: status %d", rec.Code)
+	}
+
+	sampah := ts.expect(http.StatusOK, "GET", "/transaksi/sampah", u.Token, nil)
+	items := sampah["transaksi"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("sampah: %v", sampah)
+	}
+	item := items[0].(map[string]interface{})
+	if int(num(item["id"])) != id || item["deleted_at"] == nil || item["purge_at"] == nil {
+		t.Fatalf("isi sampah: %v", item)
+	}
+
+	out = ts.expect(http.StatusOK, "POST", "/transaksi/pulihkan", u.Token, map[string]interface{}{"id": id})
+	if num(out["total_saldo"]) != 80000 {
+		t.Fatalf("pulihkan: %v", out)
+	}
+	if restored := out["transaksi"].(map[string]interface{}); restored["deleted_at"] != nil || num(restored["version"]) != 3 {
+		t.Fatalf("transaksi dipulihkan: %v", restored)
+	}
+	if sampah := ts.expect(http.StatusOK, "GET", "/transaksi/sampah", u.Token, nil); num(sampah["total_data"]) != 0 {
+		t.Fatalf("sampah setelah pulihkan: %v", sampah)
+	}
+
+	riwayat := ts.expect(http.StatusOK, "GET", fmt.Sprintf("/transaksi/riwayat?id=%d", id), u.Token, nil)
+	var actions []string
+	for _, e := range riwayat["riwayat"].([]interface{}) {
+		actions = append(actions, e.(map[string]interface{})["action"].(string))
+	}
+	if fmt.Sprint(actions) != "[create delete restore]" {
+		t.Fatalf("riwayat: %v", actions)
+	}
+
+	// 🔹 Aktivitas room dibaca per halaman, terbaru dulu
+	page := ts.expect(http.StatusOK, "GET", "/rooms/aktivitas?limit=2", u.Token, nil)
+	if num(page["total_data"]) != 2 || page["next_before_id"] == nil {
+		t.Fatalf("aktivitas halaman 1: %v", page)
+	}
+	first := page["aktivitas"].([]interface{})[0].(map[string]interface{})
+	if first["action"] != "restore" || first["user_name"] != "Andi" {
+		t.Fatalf("aktivitas terbaru: %v", first)
+	}
+	page = ts.expect(http.StatusOK, "GET", fmt.Sprintf("/rooms/aktivitas?limit=2&before_id=%d", int(num(page["next_before_id"]))), u.Token, nil)
+	if num(page["total_data"]) != 2 {
+		t.Fatalf("aktivitas halaman 2: %v", page)
+	}
+}
+
+// ==========================
+// 🔹 Kategori & tag
+// ==========================
+
+func TestKategoriDanTag(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+
+	list := ts.expect(http.StatusOK, "GET", "/kategori", u.Token, nil)
+	if num(list["total_data"]) != float64(len(defaultCategories)) {
+		t.Fatalf("kategori bawaan: %v", list)
+	}
+	out := ts.expect(http.StatusCreated, "POST", "/kategori", u.Token, map[string]interface{}{"name": "Kopi", "icon": "☕", "color": "#6F4E37"})
+	kopi := out["kategori"].(map[string]interface{})
+	if rec := ts.request("POST", "/kategori", u.Token, map[string]interface{}{"name": "kopi"}); rec.Code != http.StatusConflict {
+		t.Fatalf("nama kembar: status %d", rec.Code)
+	}
+
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 50000, "tags": []string{"gaji"}})
+	id := ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "category_id": kopi["id"], "nominal": 18000, "tags": []string{"Kantor", "pagi"}})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 2000, "tags": []string{"kantor"}})
+
+	tags := ts.expect(http.StatusOK, "GET", "/tag?q=ka", u.Token, nil)
+	got := tags["tag"].([]interface{})
+	if len(got) != 1 {
+		t.Fatalf("tag?q=ka: %v", tags)
+	}
+	if tag := got[0].(map[string]interface{}); tag["name"] != "Kantor" || num(tag["count"]) != 2 {
+		t.Fatalf("tag kantor: %v", tag)
+	}
+
+	// 🔹 Nama baru ikut dipakai transaksi lama
+	out = ts.expect(http.StatusOK, "PATCH", "/kategori", u.Token, map[string]interface{}{"id": kopi["id"], "name": "Ngopi"})
+	if num(out["transaksi_diperbarui"]) != 1 {
+		t.Fatalf("rename: %v", out)
+	}
+	rec := ts.request("GET", fmt.Sprintf("/get-transaksi?id=%d", id), u.Token, nil)
+	if tx := decodeJSON(t, rec)["transaksi"].(map[string]interface{}); tx["kategori"] != "Ngopi" || num(tx["version"]) != 2 {
+		t.Fatalf("kategori transaksi setelah rename: %v", tx)
+	}
+	if etag := rec.Header().Get("ETag"); etag != fmt.Sprintf(`"%d-2"`, id) {
+		t.Fatalf("ETag setelah rename %q", etag)
+	}
+	riwayat := ts.expect(http.StatusOK, "GET", fmt.Sprintf("/transaksi/riwayat?id=%d", id), u.Token, nil)
+	if entries := riwayat["riwayat"].([]interface{}); len(entries) != 2 || entries[1].(map[string]interface{})["action"] != "update" {
+		t.Fatalf("riwayat rename: %v", riwayat)
+	}
+
+	// 🔹 Kategori diarsipkan: tidak bisa dipakai transaksi baru
+	ts.expect(http.StatusOK, "DELETE", "/kategori", u.Token, map[string]interface{}{"id": kopi["id"]})
+	if rec := ts.request("POST", "/transaksi", u.Token, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Ngopi", "nominal": 1}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("kategori arsip dipakai: status %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// ==========================
+// 🔹 Saldo negatif saat edit
+// ==========================
+
+func TestEditTransaksiSaldoNegatif(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", map[string]interface{}{"forbid_negative_balance": true})
+	ts.expect(http.StatusOK, "POST", "/pemasukan", u.Token, map[string]interface{}{"amount": 50000})
+	ts.expect(http.StatusOK, "POST", "/pengeluaran", u.Token, map[string]interface{}{"amount": 20000})
+	id := ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 10000})
+
+	var legacyID float64
+	for _, d := range ts.expect(http.StatusOK, "GET", "/seluruh-transaksi", u.Token, nil)["data"].([]interface{}) {
+		if item := d.(map[string]interface{}); num(item["pengeluaran"]) == 20000 {
+			legacyID = num(item["id"])
+		}
+	}
+
+	cases := []struct {
+		name string
+		path string
+		body map[string]interface{}
+	}{
+		{"edit-transaksi-lainnya", "/edit-transaksi-lainnya", map[string]interface{}{"id": id, "version": 1, "nominal": 40000}},
+		{"PUT /transaksi ganti jenis", "/transaksi", map[string]interface{}{"id": id, "version": 1, "jenis": "Pengeluaran", "nominal": 30001}},
+		{"edit-transaksi-user", "/edit-transaksi-user", map[string]interface{}{"id": legacyID, "version": 1, "pengeluaran": 60000}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := ts.request("PUT", c.path, u.Token, c.body)
+			if rec.Code != http.StatusConflict || errorCode(t, rec) != "negative_balance" {
+				t.Fatalf("status %d %s, mau 409 negative_balance", rec.Code, rec.Body.String())
+			}
+			if got := ts.saldo(u); got != 20000 {
+				t.Fatalf("saldo berubah menjadi %v", got)
+			}
+		})
+	}
+
+	// Perubahan dibatalkan seluruhnya: versi dan riwayat tidak bertambah.
+	rec := ts.request("GET", fmt.Sprintf("/get-transaksi?id=%d", id), u.Token, nil)
+	if rec.Header().Get("ETag") != fmt.Sprintf(`"%d-1"`, id) {
+		t.Fatalf("versi berubah: ETag %q", rec.Header().Get("ETag"))
+	}
+	if riwayat := ts.expect(http.StatusOK, "GET", fmt.Sprintf("/transaksi/riwayat?id=%d", id), u.Token, nil); num(riwayat["total_data"]) != 1 {
+		t.Fatalf("riwayat bertambah: %v", riwayat)
+	}
+
+	// Saldo tepat nol masih boleh.
+	ts.expect(http.StatusOK, "PUT", "/edit-transaksi-lainnya", u.Token, map[string]interface{}{"id": id, "version": 1, "nominal": 30000})
+	if got := ts.saldo(u); got != 0 {
+		t.Fatalf("saldo %v, mau 0", got)
+	}
+
+	// 🔹 Tanpa forbid_negative_balance edit yang sama diterima
+	ts.expect(http.StatusOK, "PATCH", "/rooms", u.Token, map[string]interface{}{"forbid_negative_balance": false})
+	ts.expect(http.StatusOK, "PUT", "/edit-transaksi-user", u.Token, map[string]interface{}{"id": legacyID, "version": 1, "pengeluaran": 60000})
+	if got := ts.saldo(u); got != -40000 {
+		t.Fatalf("saldo %v, mau -40000", got)
+	}
+}
+
+func TestTambahTransaksiSaldoNegatif(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", map[string]interface{}{"forbid_negative_balance": true})
+	ts.expect(http.StatusOK, "POST", "/pemasukan", u.Token, map[string]interface{}{"amount": 50000})
+
+	// 🔹 Semua jalur tambah menolak saldo negatif dengan error yang sama
+	cases := []struct {
+		name string
+		path string
+		body map[string]interface{}
+	}{
+		{"pengeluaran lama", "/pengeluaran", map[string]interface{}{"amount": 50001}},
+		{"POST /transaksi", "/transaksi", map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 50001}},
+		{"transaksi-lainnya", "/transaksi-lainnya", map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 50001}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := ts.request("POST", c.path, u.Token, c.body)
+			if rec.Code != http.StatusConflict || errorCode(t, rec) != "negative_balance" {
+				t.Fatalf("status %d %s, mau 409 negative_balance", rec.Code, rec.Body.String())
+			}
+			if got := ts.saldo(u); got != 50000 {
+				t.Fatalf("saldo berubah menjadi %v", got)
+			}
+		})
+	}
+	if list := ts.expect(http.StatusOK, "GET", "/get-transaksi", u.Token, nil); num(list["total"]) != 1 {
+		t.Fatalf("transaksi yang ditolak tersimpan: %v", list)
+	}
+
+	// 🔹 Tanpa aturan room: /pengeluaran lama boleh minus, /transaksi tetap
+	// menolak pengeluaran melebihi saldo
+	ts.expect(http.StatusOK, "PATCH", "/rooms", u.Token, map[string]interface{}{"forbid_negative_balance": false})
+	if rec := ts.request("POST", "/transaksi", u.Token, cases[1].body); rec.Code != http.StatusBadRequest {
+		t.Fatalf("saldo tidak cukup: status %d %s", rec.Code, rec.Body.String())
+	}
+	ts.expect(http.StatusOK, "POST", "/pengeluaran", u.Token, cases[0].body)
+	if got := ts.saldo(u); got != -1 {
+		t.Fatalf("saldo %v, mau -1", got)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ==========================
+// 🔹 Idempotency-Key untuk POST transaksi
+// ==========================
+//
+// Client mobile mengirim ulang request saat koneksi putus. Dengan header
+// Idempotency-Key, request pertama diproses seperti biasa dan responnya
+// disimpan; request berikutnya dengan key yang sama mendapat respon yang
+// sama persis (header Idempotent-Replayed: true) tanpa membuat transaksi
+// baru. Key berlaku per user selama IDEMPOTENCY_KEY_TTL.
+//
+//   - body/endpoint berbeda dengan key yang sama → 422 idempotency_key_reused
+//   - request pertama belum selesai              → 409 idempotency_in_progress
+//   - body melebihi batas route                  → 413 request_too_large
+//
+// Respon 5xx tidak disimpan, jadi client boleh mencoba lagi dengan key yang
+// sama.
+
+const (
+	idempotencyHeader        = "Idempotency-Key"
+	maxIdempotencyKeyLen     = 255
+	idempotencyPurgeInterval = time.Hour
+)
+
+// Header respon yang ikut disimpan dan diputar ulang.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// idempotent membungkus handler POST. Request tanpa header Idempotency-Key
+// (dan method selain POST) diteruskan apa adanya. Harus dipasang di balik
+// requireRoom karena key disimpan per user.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return s.idempotentBody(maxPeekBody, next)
+}
+
+// idempotentBody sama dengan idempotent untuk route yang body-nya boleh
+// lebih besar dari maxPeekBody. Body harus dibaca utuh untuk fingerprint,
+// jadi body yang melebihi maxBody ditolak dengan 413.
+func (s *Server) idempotentBody(maxBody int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeError(w, http.StatusBadRequest, "invalid_idempotency_key", "Idempotency-Key maksimal 255 karakter")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request_too_large",
+				fmt.Sprintf("Body request dengan Idempotency-Key maksimal %d byte", maxBody))
+			return
+		}
+		if err != nil {
+			http.Error(w, "Gagal membaca body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := context.Background()
+		now := time.Now()
+		rec := IdempotencyRecord{
+			UserID:      currentUser(r.Context()).ID,
+			Key:         key,
+			Fingerprint: requestFingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(cfg.IdempotencyKeyTTL.Duration),
+		}
+		existing, reserved, err := s.store.Idempotency().Reserve(ctx, rec)
+		if err != nil {
+			http.Error(w, "Gagal memeriksa Idempotency-Key: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case existing.Fingerprint != rec.Fingerprint:
+				writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused",
+					"Idempotency-Key ini sudah dipakai untuk request yang berbeda")
+			case existing.StatusCode == 0:
+				writeError(w, http.StatusConflict, "idempotency_in_progress",
+					"Request dengan Idempotency-Key ini masih diproses, coba lagi sebentar")
+			default:
+				for name, v := range existing.Headers {
+					w.Header().Set(name, v)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.Body)
+			}
+			return
+		}
+
+		cw := &capturingWriter{ResponseWriter: w}
+		next(cw, r)
+
+		// 🔹 Simpan respon; error server tidak disimpan supaya bisa dicoba lagi
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if cw.status >= 500 {
+			if err := s.store.Idempotency().Release(ctx, rec.UserID, key); err != nil {
+				log.Printf("⚠️ Gagal melepas Idempotency-Key: %v\n", err)
+			}
+			return
+		}
+		rec.StatusCode = cw.status
+		rec.Headers = map[string]string{}
+		for _, name := range replayedHeaders {
+			if v := w.Header().Get(name); v != "" {
+				rec.Headers[name] = v
+			}
+		}
+		rec.Body = cw.body.Bytes()
+		if err := s.store.Idempotency().Complete(ctx, rec); err != nil {
+			log.Printf("⚠️ Gagal menyimpan respon Idempotency-Key: %v\n", err)
+		}
+	}
+}
+
+// requestFingerprint membedakan request berdasarkan method, path dan isi
+// body. Body JSON dinormalkan dulu supaya urutan field dan spasi tidak
+// berpengaruh.
+func requestFingerprint(r *http.Request, body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// capturingWriter meneruskan respon ke client sambil menyalin status dan
+// body-nya.
+type capturingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *capturingWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *capturingWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// startIdempotencyPurger menghapus Idempotency-Key yang sudah kedaluwarsa
+// secara berkala sampai ctx selesai. Key kedaluwarsa sudah diabaikan saat
+// Reserve; job ini hanya menjaga tabelnya tetap kecil.
+func startIdempotencyPurger(ctx context.Context, st Store, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := st.Idempotency().PurgeExpired(ctx, time.Now()); err != nil {
+				log.Printf("⚠️ Gagal membersihkan Idempotency-Key: %v\n", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIdempotentReplay(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	body := map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 50000}
+
+	first := ts.request("POST", "/transaksi", u.Token, body, "Idempotency-Key", "kunci-1")
+	if first.Code != http.StatusOK || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("request pertama: %d %v", first.Code, first.Header())
+	}
+
+	// 🔹 Urutan field berbeda tetap dianggap request yang sama
+	again := ts.request("POST", "/transaksi", u.Token, `{"nominal":50000,"kategori":"Lainnya","jenis":"Pemasukan"}`, "Idempotency-Key", "kunci-1")
+	if again.Code != http.StatusOK || again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay: %d %v", again.Code, again.Header())
+	}
+	if again.Body.String() != first.Body.String() || again.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Fatalf("respon replay berbeda:\n%s\n%s", first.Body.String(), again.Body.String())
+	}
+
+	body["nominal"] = 60000
+	rec := ts.request("POST", "/transaksi", u.Token, body, "Idempotency-Key", "kunci-1")
+	if rec.Code != http.StatusUnprocessableEntity || errorCode(t, rec) != "idempotency_key_reused" {
+		t.Fatalf("key dipakai ulang: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := ts.request("POST", "/pemasukan", u.Token, map[string]interface{}{"amount": 50000}, "Idempotency-Key", "kunci-1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("key dipakai di endpoint lain: %d", rec.Code)
+	}
+
+	// 🔹 Key berlaku per user
+	bela := ts.register("Bela")
+	ts.join(u, bela)
+	if rec := ts.request("POST", "/transaksi", bela.Token, body, "Idempotency-Key", "kunci-1"); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("key user lain: %d %v", rec.Code, rec.Header())
+	}
+
+	list := ts.expect(http.StatusOK, "GET", "/get-transaksi", u.Token, nil)
+	if num(list["total_data"]) != 2 || ts.saldo(u) != 110000 {
+		t.Fatalf("transaksi ganda: %v", list)
+	}
+}
+
+func TestIdempotentErrorTidakDisimpan(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+
+	if rec := ts.request("POST", "/transaksi", u.Token, map[string]interface{}{"jenis": "Pemasukan", "nominal": 1}, "Idempotency-Key", "k"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("request salah: %d", rec.Code)
+	}
+	// Respon 4xx disimpan: key yang sama mendapat respon yang sama.
+	rec := ts.request("POST", "/transaksi", u.Token, map[string]interface{}{"jenis": "Pemasukan", "nominal": 1}, "Idempotency-Key", "k")
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay 4xx: %d %v", rec.Code, rec.Header())
+	}
+	if rec := ts.request("POST", "/transaksi", u.Token, nil, "Idempotency-Key", string(make([]byte, 300))); rec.Code != http.StatusBadRequest {
+		t.Fatalf("key terlalu panjang: %d", rec.Code)
+	}
+}
+
+func TestIdempotentBodyLimit(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+
+	body := map[string]interface{}{
+		"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 1,
+		"keterangan": strings.Repeat("x", maxPeekBody),
+	}
+	rec := ts.request("POST", "/transaksi", u.Token, body, "Idempotency-Key", "besar")
+	if rec.Code != http.StatusRequestEntityTooLarge || errorCode(t, rec) != "request_too_large" {
+		t.Fatalf("body melebihi batas: %d %s", rec.Code, rec.Body.String())
+	}
+	if list := ts.expect(http.StatusOK, "GET", "/get-transaksi", u.Token, nil); num(list["total"]) != 0 {
+		t.Fatalf("transaksi tersimpan: %v", list)
+	}
+}
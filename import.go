@@ -0,0 +1,492 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Import transaksi (preview & commit)
+// ==========================
+//
+// POST /transaksi/import menerima upload multipart (field "file") dan
+// selalu berjalan dua langkah dengan file yang sama:
+//
+//  1. dry_run=true (default): setiap baris divalidasi dengan aturan yang
+//     sama seperti TambahTransaksi (validJenis, kategori aktif room,
+//     validateAmount) dan dicocokkan dengan transaksi yang sudah ada untuk
+//     menandai kemungkinan duplikat. Tidak ada yang disimpan.
+//  2. dry_run=false: semua baris disimpan dalam satu transaksi DB dengan
+//     satu kali update saldo room. Jika ada baris tidak valid seluruh batch
+//     ditolak (422), kecuali skip_invalid=true. Duplikat dilewati, kecuali
+//     include_duplicates=true.
+//
+// Field format (csv, ofx, qfx, camt053; default ditebak dari nama & isi
+// file) memilih parser (lihat import_csv.go dan import_bank.go). Parser
+// hanya mengubah file menjadi importRow, validasi & penyimpanan ada di
+// sini. Baris dengan ID transaksi bank (external_id) yang sudah pernah
+// diimport selalu dilewati.
+
+const (
+	maxImportSize    = 5 << 20               // 5 MB
+	maxImportRequest = maxImportSize + 1<<20 // file + field form lain
+	maxImportRows    = 5000
+)
+
+// importIssue adalah satu masalah validasi di sebuah baris.
+type importIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// importRow adalah satu calon transaksi hasil parser.
+type importRow struct {
+	Line       int       `json:"baris"` // nomor baris/entri di file asal
+	OccurredAt time.Time `json:"occurred_at"`
+	Jenis      string    `json:"jenis"`
+	Nominal    Money     `json:"nominal"`
+	Kategori   string    `json:"kategori"`
+	CategoryID int       `json:"category_id,omitempty"`
+	Keterangan string    `json:"keterangan"`
+	UserName   string    `json:"user,omitempty"` // nama/email anggota room, kosong = pengunggah
+	UserID     int       `json:"user_id"`
+	Tags       []string  `json:"tags"`
+	ExternalID string    `json:"external_id,omitempty"` // id transaksi dari bank
+	Currency   string    `json:"currency,omitempty"`    // mata uang di file, kosong = mata uang room
+
+	Errors []importIssue `json:"errors"`
+	// Kemungkinan duplikat: transaksi yang sudah ada, atau baris lain di
+	// file yang sama.
+	DuplicateOf     int `json:"duplicate_of_transaction,omitempty"`
+	DuplicateOfLine int `json:"duplicate_of_baris,omitempty"`
+	// AlreadyImported berarti duplikat pasti (external_id sama), bukan
+	// sekadar tanggal/jenis/nominal yang mirip.
+	AlreadyImported bool `json:"already_imported,omitempty"`
+}
+
+func (r *importRow) fail(field, msg string) {
+	r.Errors = append(r.Errors, importIssue{Field: field, Message: msg})
+}
+
+func (r importRow) valid() bool     { return len(r.Errors) == 0 }
+func (r importRow) duplicate() bool { return r.DuplicateOf != 0 || r.DuplicateOfLine != 0 }
+
+// importParsers adalah format file yang bisa diimport (?format= atau field
+// form format). Setiap parser mengubah file menjadi importRow; kesalahan
+// per baris dicatat di importRow.Errors.
+var importParsers = map[string]func(data []byte, r *http.Request, tz *time.Location) ([]importRow, error){
+	"csv":     parseCSVImport,
+	"ofx":     parseOFXImport,
+	"qfx":     parseOFXImport,
+	"camt053": parseCAMTImport,
+}
+
+// importFormat menebak format dari ekstensi lalu isi file jika client tidak
+// mengirim format.
+func importFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ofx":
+		return "ofx"
+	case ".qfx":
+		return "qfx"
+	case ".xml":
+		return "camt053"
+	case ".csv", ".txt":
+		return "csv"
+	}
+	head := strings.ToUpper(string(data[:min(len(data), 1024)]))
+	switch {
+	case strings.Contains(head, "OFXHEADER") || strings.Contains(head, "<OFX>"):
+		return "ofx"
+	case strings.Contains(head, "CAMT.053"):
+		return "camt053"
+	}
+	return "csv"
+}
+
+// importOptions adalah opsi umum semua format import.
+type importOptions struct {
+	DryRun            bool
+	SkipInvalid       bool
+	IncludeDuplicates bool
+	DefaultCategory   string
+}
+
+func readImportOptions(r *http.Request) (importOptions, error) {
+	opts := importOptions{DryRun: true, DefaultCategory: strings.TrimSpace(r.FormValue("default_category"))}
+	for name, dst := range map[string]*bool{
+		"dry_run":            &opts.DryRun,
+		"skip_invalid":       &opts.SkipInvalid,
+		"include_duplicates": &opts.IncludeDuplicates,
+	} {
+		if v := r.FormValue(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return opts, fmt.Errorf("%s harus true atau false", name)
+			}
+			*dst = b
+		}
+	}
+	return opts, nil
+}
+
+// duplicateKey menganggap dua transaksi kemungkinan sama jika tanggal (di
+// zona waktu room), jenis dan nominalnya sama.
+func duplicateKey(t time.Time, tz *time.Location, jenis string, nominal Money) string {
+	return t.In(tz).Format("2006-01-02") + "|" + jenis + "|" + nominal.Fixed()
+}
+
+// validateImportRows melengkapi dan memvalidasi rows untuk room, lalu
+// menandai duplikat.
+func (s *Server) validateImportRows(ctx context.Context, room Room, caller authUser, rows []importRow, opts importOptions) error {
+	members, err := s.store.Users().ListByRoom(ctx, room.ID)
+	if err != nil {
+		return fmt.Errorf("Gagal membaca anggota room: %w", err)
+	}
+
+	type kategoriResult struct {
+		c   Category
+		err error
+	}
+	kategori := map[string]kategoriResult{} // file besar biasanya memakai sedikit kategori
+
+	var from, to time.Time
+	for i := range rows {
+		row := &rows[i]
+
+		if row.OccurredAt.IsZero() && !hasIssue(row, "occurred_at") {
+			row.fail("occurred_at", "tanggal wajib diisi")
+		}
+		row.Jenis = normalizeLabel(row.Jenis)
+		if !validJenis[row.Jenis] && !hasIssue(row, "jenis") {
+			row.fail("jenis", "jenis harus Pemasukan atau Pengeluaran")
+		}
+		if !hasIssue(row, "nominal") {
+			if row.Nominal <= 0 {
+				row.fail("nominal", "nominal harus lebih dari 0")
+			} else if msg := validateAmount(row.Nominal, room.Currency); msg != "" {
+				row.fail("nominal", msg)
+			}
+		}
+		if row.Currency != "" && !strings.EqualFold(row.Currency, room.Currency) {
+			row.fail("currency", fmt.Sprintf("Mata uang %s berbeda dengan mata uang room (%s)", row.Currency, room.Currency))
+		}
+		if tags, msg := normalizeTags(row.Tags); msg != "" {
+			row.fail("tags", msg)
+		} else {
+			row.Tags = tags
+		}
+
+		// 🔹 Kategori harus kategori aktif milik room (sama seperti TambahTransaksi)
+		name := row.Kategori
+		if strings.TrimSpace(name) == "" {
+			name = opts.DefaultCategory
+		}
+		key := strings.ToLower(strings.TrimSpace(name))
+		res, ok := kategori[key]
+		if !ok {
+			res.c, res.err = resolveKategori(ctx, s.store, room.ID, 0, name)
+			kategori[key] = res
+		}
+		c, err := res.c, res.err
+		var he *httpError
+		switch {
+		case errors.As(err, &he):
+			row.fail("kategori", he.Message)
+		case err != nil:
+			return err
+		default:
+			row.Kategori, row.CategoryID = c.Name, c.ID
+		}
+
+		// 🔹 Anggota room berdasarkan nama atau email; kosong = pengunggah
+		row.UserID = caller.ID
+		if u := strings.TrimSpace(row.UserName); u != "" {
+			row.UserID = 0
+			for _, m := range members {
+				if strings.EqualFold(m.FullName, u) || strings.EqualFold(m.Email, u) {
+					row.UserID = m.ID
+					break
+				}
+			}
+			if row.UserID == 0 {
+				row.fail("user", "User "+u+" bukan anggota room ini")
+			}
+		}
+
+		if !row.OccurredAt.IsZero() {
+			if from.IsZero() || row.OccurredAt.Before(from) {
+				from = row.OccurredAt
+			}
+			if to.IsZero() || row.OccurredAt.After(to) {
+				to = row.OccurredAt
+			}
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+
+	// 🔹 Duplikat pasti: ID transaksi bank yang sudah tersimpan atau muncul
+	// dua kali di file
+	var externalIDs []string
+	for _, row := range rows {
+		if row.ExternalID != "" {
+			externalIDs = append(externalIDs, row.ExternalID)
+		}
+	}
+	imported, err := s.store.Transactions().ExternalIDs(ctx, room.ID, externalIDs)
+	if err != nil {
+		return fmt.Errorf("Gagal memeriksa ID transaksi bank: %w", err)
+	}
+	seenExternal := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+		if row.ExternalID == "" {
+			continue
+		}
+		if id, ok := imported[row.ExternalID]; ok {
+			row.DuplicateOf, row.AlreadyImported = id, true
+		} else if line, ok := seenExternal[row.ExternalID]; ok {
+			row.DuplicateOfLine, row.AlreadyImported = line, true
+		} else {
+			seenExternal[row.ExternalID] = row.Line
+		}
+	}
+
+	// 🔹 Tandai kemungkinan duplikat (dengan data lama & sesama baris file)
+	tz := roomLocation(room)
+	existing := map[string]int{}
+	day := func(t time.Time) time.Time {
+		t = t.In(tz)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tz)
+	}
+	filter := LedgerFilter{RoomID: room.ID, From: day(from), To: day(to).AddDate(0, 0, 1)}
+	err = s.store.Transactions().Each(ctx, filter, func(t Transaction) error {
+		key := duplicateKey(t.OccurredAt, tz, t.Jenis, t.Nominal)
+		if _, ok := existing[key]; !ok {
+			existing[key] = t.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Gagal memeriksa duplikat: %w", err)
+	}
+	seen := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+		if row.OccurredAt.IsZero() || row.AlreadyImported {
+			continue
+		}
+		key := duplicateKey(row.OccurredAt, tz, row.Jenis, row.Nominal)
+		if id, ok := existing[key]; ok {
+			row.DuplicateOf = id
+		} else if line, ok := seen[key]; ok {
+			row.DuplicateOfLine = line
+		} else {
+			seen[key] = row.Line
+		}
+	}
+	return nil
+}
+
+func hasIssue(row *importRow, field string) bool {
+	for _, e := range row.Errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// runImport menjalankan preview atau commit untuk rows hasil parser dan
+// menulis responnya.
+func (s *Server) runImport(w http.ResponseWriter, r *http.Request, room Room, rows []importRow, opts importOptions) {
+	ctx := context.Background()
+	caller := currentUser(r.Context())
+	if len(rows) == 0 {
+		writeError(w, http.StatusBadRequest, "empty_import", "File tidak berisi transaksi")
+		return
+	}
+	if len(rows) > maxImportRows {
+		writeError(w, http.StatusRequestEntityTooLarge, "too_many_rows",
+			fmt.Sprintf("Maksimal %d transaksi per import", maxImportRows))
+		return
+	}
+
+	if err := s.validateImportRows(ctx, room, caller, rows, opts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var invalid, duplicates, alreadyImported int
+	for _, row := range rows {
+		switch {
+		case !row.valid():
+			invalid++
+		case row.AlreadyImported:
+			alreadyImported++
+		case row.duplicate():
+			duplicates++
+		}
+	}
+	preview := map[string]interface{}{
+		"status":           "success",
+		"dry_run":          opts.DryRun,
+		"total_rows":       len(rows),
+		"valid":            len(rows) - invalid,
+		"invalid":          invalid,
+		"duplicates":       duplicates,
+		"already_imported": alreadyImported,
+		"rows":             rows,
+	}
+
+	if opts.DryRun {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preview)
+		return
+	}
+	if invalid > 0 && !opts.SkipInvalid {
+		preview["status"] = "error"
+		preview["code"] = "invalid_rows"
+		preview["message"] = fmt.Sprintf("%d baris tidak valid; perbaiki file atau kirim skip_invalid=true", invalid)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(preview)
+		return
+	}
+
+	// 🔹 Simpan seluruh batch dalam satu transaksi DB, saldo diupdate sekali
+	var (
+		ids        []int
+		totalSaldo Money
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		saldo, err := lockRoomBalance(ctx, tx, room.ID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if !row.valid() || row.AlreadyImported || (row.duplicate() && !opts.IncludeDuplicates) {
+				continue
+			}
+			t := Transaction{
+				UserID:     row.UserID,
+				RoomID:     room.ID,
+				Jenis:      row.Jenis,
+				Kategori:   row.Kategori,
+				CategoryID: row.CategoryID,
+				Nominal:    row.Nominal,
+				Keterangan: row.Keterangan,
+				OccurredAt: row.OccurredAt,
+				ExternalID: row.ExternalID,
+			}
+			if err := tx.Transactions().Create(ctx, &t); err != nil {
+				return fmt.Errorf("Gagal menyimpan baris %d: %w", row.Line, err)
+			}
+			t.Tags = row.Tags
+			if err := setTransactionTags(ctx, tx, &t); err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, tx, caller.ID, auditCreate, nil, &t, t.Delta()); err != nil {
+				return err
+			}
+			saldo += t.Delta()
+			ids = append(ids, t.ID)
+		}
+		if err := guardNegativeBalance(ctx, tx, room.ID, saldo); err != nil {
+			return err
+		}
+		totalSaldo = saldo
+		return setRoomBalance(ctx, tx, room.ID, saldo)
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	skippedDuplicates := duplicates
+	if opts.IncludeDuplicates {
+		skippedDuplicates = 0
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":             "success",
+		"message":            fmt.Sprintf("%d transaksi berhasil diimport", len(ids)),
+		"imported":           len(ids),
+		"skipped_invalid":    invalid,
+		"skipped_duplicates": skippedDuplicates,
+		"already_imported":   alreadyImported,
+		"transaction_ids":    ids,
+		"total_saldo":        totalSaldo,
+		"summary":            s.getUserSummary(room.ID, caller.ID),
+	})
+}
+
+// ==========================
+// 🔹 API: Import transaksi
+// ==========================
+func (s *Server) ImportTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportRequest)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Upload harus multipart/form-data dengan field file (maks 5 MB)")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Field file wajib diisi")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		http.Error(w, "Gagal membaca file", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxImportSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "file_too_large", "Ukuran file maksimal 5 MB")
+		return
+	}
+
+	opts, err := readImportOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_option", err.Error())
+		return
+	}
+	format := strings.ToLower(r.FormValue("format"))
+	if format == "" {
+		format = importFormat(header.Filename, data)
+	}
+	parse, ok := importParsers[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_format", "format harus csv, ofx, qfx atau camt053")
+		return
+	}
+
+	ctx := context.Background()
+	room, err := s.store.Rooms().Get(ctx, currentUser(r.Context()).RoomID)
+	if err != nil {
+		http.Error(w, "Gagal membaca room: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := parse(data, r, roomLocation(room))
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+	s.runImport(w, r, room, rows, opts)
+}
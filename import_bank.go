@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Import mutasi rekening: OFX/QFX & CAMT.053
+// ==========================
+//
+// Kedua parser menghasilkan importRow untuk alur preview/commit yang sama
+// dengan CSV (import.go). Arah mutasi menentukan jenis: debit (uang keluar)
+// = Pengeluaran, kredit = Pemasukan. ID transaksi dari bank disimpan sebagai
+// external_id ("ofx:<rekening>:<FITID>", "camt:<rekening>:<ref>") sehingga
+// file mutasi yang tumpang tindih tidak membuat transaksi ganda. Kategori
+// tidak ada di file bank, jadi biasanya diisi lewat default_category.
+// Contoh file ada di testdata/import.
+
+func invalidStatement(format, msg string) error {
+	return clientError(http.StatusBadRequest, "invalid_file", "File "+format+" tidak valid: "+msg)
+}
+
+// joinNonEmpty menggabungkan bagian yang tidak kosong dan tidak berulang.
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		p = strings.Join(strings.Fields(p), " ")
+		dup := false
+		for _, o := range out {
+			dup = dup || strings.EqualFold(o, p)
+		}
+		if p != "" && !dup {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " - ")
+}
+
+// ==========================
+// 🔹 OFX / QFX
+// ==========================
+//
+// OFX 1.x berformat SGML (tag penutup boleh tidak ada) sedangkan OFX 2.x
+// berformat XML; QFX adalah OFX dari Quicken. Parser membaca tag demi tag
+// sehingga ketiganya bisa dibaca dengan cara yang sama: setiap <STMTTRN>
+// menjadi satu baris, rekening & mata uang diambil dari statement yang
+// sedang dibaca (satu file boleh berisi beberapa rekening).
+
+func parseOFXImport(data []byte, _ *http.Request, tz *time.Location) ([]importRow, error) {
+	start := bytes.IndexByte(data, '<')
+	if start < 0 || !bytes.Contains(bytes.ToUpper(data), []byte("<OFX>")) {
+		return nil, invalidStatement("OFX", "tag <OFX> tidak ditemukan")
+	}
+
+	var (
+		rows              []importRow
+		account, currency string
+		trn               map[string]string // STMTTRN yang sedang dibaca
+	)
+	text := string(data[start:])
+	for {
+		i := strings.IndexByte(text, '<')
+		if i < 0 {
+			break
+		}
+		text = text[i+1:]
+		j := strings.IndexByte(text, '>')
+		if j < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(text[:j]))
+		text = text[j+1:]
+		end := strings.IndexByte(text, '<')
+		if end < 0 {
+			end = len(text)
+		}
+		value := html.UnescapeString(strings.TrimSpace(text[:end]))
+
+		switch {
+		case tag == "STMTTRN":
+			trn = map[string]string{}
+		case tag == "/STMTTRN":
+			if trn != nil {
+				rows = append(rows, ofxRow(trn, len(rows)+1, account, currency, tz))
+				trn = nil
+			}
+		case strings.HasPrefix(tag, "/"), strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+		case trn != nil:
+			if _, ok := trn[tag]; !ok { // NAME di dalam PAYEE tidak menimpa NAME
+				trn[tag] = value
+			}
+		case tag == "CURDEF":
+			currency = value
+		case tag == "ACCTID":
+			account = value
+		}
+		if len(rows) > maxImportRows {
+			break
+		}
+	}
+	if trn != nil { // SGML tanpa </STMTTRN> di akhir file
+		rows = append(rows, ofxRow(trn, len(rows)+1, account, currency, tz))
+	}
+	return rows, nil
+}
+
+func ofxRow(trn map[string]string, line int, account, currency string, tz *time.Location) importRow {
+	row := importRow{Line: line, Currency: currency, Tags: []string{}}
+
+	date := trn["DTPOSTED"]
+	if date == "" {
+		date = trn["DTUSER"]
+	}
+	t, err := parseOFXDate(date, tz)
+	if err != nil {
+		row.fail("occurred_at", "DTPOSTED tidak valid: "+date)
+	}
+	row.OccurredAt = t
+
+	// Tanda TRNAMT yang menentukan arah; TRNTYPE hanya keterangan tambahan.
+	amount := trn["TRNAMT"]
+	sep := "."
+	if strings.Contains(amount, ",") && !strings.Contains(amount, ".") {
+		sep = ","
+	}
+	m, err := parseImportAmount(amount, sep)
+	if err != nil || amount == "" {
+		row.fail("nominal", "TRNAMT tidak valid: "+amount)
+	}
+	row.Jenis, row.Nominal = "Pemasukan", m
+	if m < 0 {
+		row.Jenis, row.Nominal = "Pengeluaran", -m
+	}
+
+	if id := trn["FITID"]; id != "" {
+		row.ExternalID = "ofx:" + account + ":" + id
+	}
+	row.Keterangan = joinNonEmpty(trn["NAME"], trn["MEMO"])
+	return row
+}
+
+// parseOFXDate membaca tanggal OFX: YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]].
+// Tanpa offset berarti zona waktu room.
+func parseOFXDate(s string, tz *time.Location) (time.Time, error) {
+	loc := tz
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		zone := strings.TrimSuffix(s[i+1:], "]")
+		s = s[:i]
+		offset, name, _ := strings.Cut(zone, ":")
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = time.FixedZone(name, int(hours*3600))
+	}
+	s, _, _ = strings.Cut(s, ".")
+	layout := "20060102150405"
+	if len(s) < len(layout) {
+		layout = layout[:len(s)]
+	}
+	return time.ParseInLocation(layout, s, loc)
+}
+
+// ==========================
+// 🔹 CAMT.053 (ISO 20022)
+// ==========================
+//
+// Dibaca tanpa memperhatikan namespace, jadi versi camt.053.001.02 sampai
+// .08 bisa dipakai. Satu <Ntry> menjadi satu baris (entri batch tidak
+// dipecah per TxDtls). Entri yang belum dibukukan (status selain BOOK)
+// ditandai tidak valid.
+
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	IBAN    string      `xml:"Acct>Id>IBAN"`
+	Other   string      `xml:"Acct>Id>Othr>Id"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtEntry struct {
+	Ref    string `xml:"NtryRef"`
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+	Status    struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"` // camt.053.001.08 ke atas
+	} `xml:"Sts"`
+	BookingDate camtDate        `xml:"BookgDt"`
+	ValueDate   camtDate        `xml:"ValDt"`
+	ServicerRef string          `xml:"AcctSvcrRef"`
+	Info        string          `xml:"AddtlNtryInf"`
+	Details     []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTxDetails struct {
+	ServicerRef  string   `xml:"Refs>AcctSvcrRef"`
+	TxID         string   `xml:"Refs>TxId"`
+	EndToEndID   string   `xml:"Refs>EndToEndId"`
+	Creditor     string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPty  string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Debtor       string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPty    string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	Unstructured []string `xml:"RmtInf>Ustrd"`
+	Info         string   `xml:"AddtlTxInf"`
+}
+
+func parseCAMTImport(data []byte, _ *http.Request, tz *time.Location) ([]importRow, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, invalidStatement("CAMT.053", err.Error())
+	}
+	if len(doc.Statements) == 0 {
+		return nil, invalidStatement("CAMT.053", "elemen BkToCstmrStmt/Stmt tidak ditemukan")
+	}
+
+	var rows []importRow
+	for _, st := range doc.Statements {
+		account := st.IBAN
+		if account == "" {
+			account = st.Other
+		}
+		for _, e := range st.Entries {
+			rows = append(rows, camtRow(e, len(rows)+1, account, tz))
+			if len(rows) > maxImportRows {
+				return rows, nil
+			}
+		}
+	}
+	return rows, nil
+}
+
+func camtRow(e camtEntry, line int, account string, tz *time.Location) importRow {
+	row := importRow{Line: line, Currency: e.Amount.Currency, Tags: []string{}}
+
+	status := strings.TrimSpace(e.Status.Code)
+	if status == "" {
+		status = strings.TrimSpace(e.Status.Value)
+	}
+	if status != "" && status != "BOOK" {
+		row.fail("status", "Entri belum dibukukan bank (status "+status+")")
+	}
+
+	date := e.BookingDate
+	if date.Date == "" && date.DateTime == "" {
+		date = e.ValueDate
+	}
+	raw := date.Date
+	if raw == "" {
+		raw = date.DateTime
+	}
+	t, err := parseOccurredAt(raw, tz)
+	if err != nil {
+		row.fail("occurred_at", "BookgDt tidak valid: "+raw)
+	}
+	row.OccurredAt = t
+
+	m, err := parseImportAmount(e.Amount.Value, ".")
+	if err != nil || strings.TrimSpace(e.Amount.Value) == "" {
+		row.fail("nominal", "Amt tidak valid: "+e.Amount.Value)
+	}
+	row.Nominal = m
+
+	var d camtTxDetails
+	if len(e.Details) > 0 {
+		d = e.Details[0]
+	}
+	counterparty := ""
+	switch e.Indicator {
+	case "DBIT":
+		row.Jenis = "Pengeluaran"
+		counterparty = joinNonEmpty(d.Creditor, d.CreditorPty)
+	case "CRDT":
+		row.Jenis = "Pemasukan"
+		counterparty = joinNonEmpty(d.Debtor, d.DebtorPty)
+	default:
+		row.fail("jenis", "CdtDbtInd harus DBIT atau CRDT")
+	}
+
+	for _, ref := range []string{e.ServicerRef, d.ServicerRef, d.TxID, d.EndToEndID, e.Ref} {
+		if ref = strings.TrimSpace(ref); ref != "" && ref != "NOTPROVIDED" {
+			row.ExternalID = "camt:" + account + ":" + ref
+			break
+		}
+	}
+
+	parts := append([]string{counterparty}, d.Unstructured...)
+	row.Keterangan = joinNonEmpty(append(parts, d.Info, e.Info)...)
+	return row
+}
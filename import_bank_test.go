@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+var wib = time.FixedZone("WIB", 7*3600)
+
+// bankRow adalah field importRow yang diperiksa test parser bank.
+type bankRow struct {
+	jenis      string
+	nominal    Money
+	externalID string
+	occurredAt time.Time
+	keterangan string
+	currency   string
+	errField   string // field error pertama, kosong = valid
+}
+
+func readSample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/import/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func checkBankRows(t *testing.T, rows []importRow, want []bankRow) {
+	t.Helper()
+	if len(rows) != len(want) {
+		t.Fatalf("%d baris, mau %d: %+v", len(rows), len(want), rows)
+	}
+	for i, w := range want {
+		r := rows[i]
+		if r.Line != i+1 {
+			t.Errorf("baris %d: Line %d", i+1, r.Line)
+		}
+		if r.Jenis != w.jenis || r.Nominal != w.nominal || r.ExternalID != w.externalID || r.Keterangan != w.keterangan || r.Currency != w.currency {
+			t.Errorf("baris %d:\n got %s %s %q %q %s\nwant %s %s %q %q %s", i+1,
+				r.Jenis, r.Nominal, r.ExternalID, r.Keterangan, r.Currency,
+				w.jenis, w.nominal, w.externalID, w.keterangan, w.currency)
+		}
+		if !r.OccurredAt.Equal(w.occurredAt) {
+			t.Errorf("baris %d: occurred_at %s, mau %s", i+1, r.OccurredAt, w.occurredAt)
+		}
+		errField := ""
+		if len(r.Errors) > 0 {
+			errField = r.Errors[0].Field
+		}
+		if errField != w.errField {
+			t.Errorf("baris %d: error %+v, mau field %q", i+1, r.Errors, w.errField)
+		}
+	}
+}
+
+func TestParseOFXImport(t *testing.T) {
+	rows, err := parseOFXImport(readSample(t, "mutasi.ofx"), nil, wib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBankRows(t, rows, []bankRow{
+		{"Pemasukan", 8500000 * moneyScale, "ofx:1234567890:202603010001", time.Date(2026, 3, 1, 0, 0, 0, 0, wib), "PT MAJU JAYA - GAJI MARET 2026", "IDR", ""},
+		// DTPOSTED dengan offset [+7:WIB]
+		{"Pengeluaran", 125500 * moneyScale, "ofx:1234567890:202603020007", time.Date(2026, 3, 2, 9, 30, 0, 0, wib), "SUPERMARKET SEGAR - DEBIT BCA 02/03", "IDR", ""},
+		// NAME dan MEMO yang sama tidak diulang; &amp; di-unescape
+		{"Pengeluaran", 350000 * moneyScale, "ofx:1234567890:202603050002", time.Date(2026, 3, 5, 0, 0, 0, 0, wib), "PLN & PDAM", "IDR", ""},
+		// Nominal dengan koma desimal; NAME diambil dari dalam PAYEE
+		{"Pengeluaran", 45000 * moneyScale, "ofx:1234567890:202603100011", time.Date(2026, 3, 10, 0, 0, 0, 0, wib), "KOPI KENANGAN - QRIS", "IDR", ""},
+	})
+}
+
+func TestParseQFXImport(t *testing.T) {
+	rows, err := parseOFXImport(readSample(t, "mutasi.qfx"), nil, wib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBankRows(t, rows, []bankRow{
+		// DTPOSTED dengan offset [0:GMT]
+		{"Pengeluaran", 275000 * moneyScale, "ofx:4111XXXXXXXX1111:CC-20260303-0001", time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC), "TOKO BUKU GRAMEDIA", "IDR", ""},
+		{"Pemasukan", 275000 * moneyScale, "ofx:4111XXXXXXXX1111:CC-20260320-0004", time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC), "PEMBAYARAN KARTU - TERIMA KASIH", "IDR", ""},
+	})
+}
+
+func TestParseOFXPayeeName(t *testing.T) {
+	// NAME transaksi yang sudah ada tidak ditimpa NAME di dalam PAYEE.
+	data := []byte(`<OFX><CURDEF>IDR<ACCTID>1
+<STMTTRN><DTPOSTED>20260311<TRNAMT>-10000<FITID>X1
+<NAME>GOPAY<PAYEE><NAME>WARUNG MAKAN<ADDR1>BANDUNG</PAYEE><MEMO>TOPUP
+</STMTTRN>
+<STMTTRN><DTPOSTED>20260312<TRNAMT>oops<FITID>X2<DTUSER>bukan-tanggal
+</OFX>`)
+	rows, err := parseOFXImport(data, nil, wib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Keterangan != "GOPAY - TOPUP" {
+		t.Fatalf("PAYEE/NAME: %+v", rows)
+	}
+	// STMTTRN tanpa penutup di akhir file tetap dibaca, dengan error nominal
+	if rows[1].ExternalID != "ofx:1:X2" || len(rows[1].Errors) != 1 || rows[1].Errors[0].Field != "nominal" {
+		t.Fatalf("baris rusak: %+v", rows[1])
+	}
+
+	if _, err := parseOFXImport([]byte("Tanggal,Nominal\n"), nil, wib); err == nil {
+		t.Fatal("file tanpa <OFX> diterima")
+	}
+}
+
+func TestParseCAMTImport(t *testing.T) {
+	rows, err := parseCAMTImport(readSample(t, "camt053.xml"), nil, wib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const acct = "camt:ID12BANK0000001234567890:"
+	checkBankRows(t, rows, []bankRow{
+		{"Pemasukan", 8500000 * moneyScale, acct + "20260301-CR-000123", time.Date(2026, 3, 1, 0, 0, 0, 0, wib), "PT Maju Jaya - Gaji Maret 2026", "IDR", ""},
+		// BookgDt/DtTm dengan offset; EndToEndId NOTPROVIDED diabaikan
+		{"Pengeluaran", 1250000 * moneyScale, acct + "20260304-DB-000456", time.Date(2026, 3, 4, 10, 15, 0, 0, wib), "Kos Melati - Sewa kamar - Maret 2026", "IDR", ""},
+		// Tanpa NtryRef/AcctSvcrRef di entri: ref dari TxDtls
+		{"Pengeluaran", 65000 * moneyScale, acct + "20260306-DB-000789", time.Date(2026, 3, 6, 0, 0, 0, 0, wib), "QRIS Warung Bu Sri", "IDR", ""},
+		// Status PDNG belum dibukukan
+		{"Pengeluaran", 200000 * moneyScale, acct + "4", time.Date(2026, 3, 31, 0, 0, 0, 0, wib), "Transfer antar bank (diproses)", "IDR", "status"},
+	})
+}
+
+func TestParseCAMTv08Import(t *testing.T) {
+	rows, err := parseCAMTImport(readSample(t, "camt053_v08.xml"), nil, wib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBankRows(t, rows, []bankRow{
+		// Rekening tanpa IBAN (Othr/Id), nama pihak di Pty/Nm
+		{"Pengeluaran", 150000 * moneyScale, "camt:0987654321:B-20260308-01", time.Date(2026, 3, 8, 0, 0, 0, 0, wib), "Apotek Sehat - Obat flu", "IDR", ""},
+		// 12.50 EUR; mata uang baru ditolak saat validasi (lihat
+		// TestImportBankStatement)
+		{"Pemasukan", 1250, "camt:0987654321:B-20260309-02", time.Date(2026, 3, 9, 0, 0, 0, 0, wib), "Refund Online Store", "EUR", ""},
+	})
+
+	if _, err := parseCAMTImport([]byte("<Document><Foo/></Document>"), nil, wib); err == nil {
+		t.Fatal("dokumen tanpa Stmt diterima")
+	}
+}
+
+func TestImportBankStatement(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", map[string]interface{}{"currency": "IDR", "timezone": "Asia/Jakarta"})
+
+	// 🔹 Entri EUR di room IDR dan entri PDNG ditolak validasi
+	for name, errors := range map[string][]string{
+		"camt053_v08.xml": {"", "currency"},
+		"camt053.xml":     {"", "", "", "status"},
+	} {
+		res := ts.upload(u, name, readSample(t, name), map[string]string{"default_category": "Lainnya"})
+		if res.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", name, res.Code, res.Raw)
+		}
+		rows := res.Body["rows"].([]interface{})
+		if len(rows) != len(errors) {
+			t.Fatalf("%s: %d baris", name, len(rows))
+		}
+		for i, field := range errors {
+			var got []string
+			errs, _ := rows[i].(map[string]interface{})["errors"].([]interface{})
+			for _, e := range errs {
+				got = append(got, e.(map[string]interface{})["field"].(string))
+			}
+			if (field == "") != (len(got) == 0) || (field != "" && !slices.Contains(got, field)) {
+				t.Fatalf("%s baris %d: error %v, mau %q", name, i+1, got, field)
+			}
+		}
+	}
+
+	// 🔹 Mutasi yang sama diimport dua kali: external_id mencegah transaksi ganda
+	opts := map[string]string{"dry_run": "false", "default_category": "Lainnya", "include_duplicates": "true"}
+	res := ts.upload(u, "mutasi.ofx", readSample(t, "mutasi.ofx"), opts)
+	if res.Code != http.StatusOK || num(res.Body["imported"]) != 4 {
+		t.Fatalf("import OFX: %d %s", res.Code, res.Raw)
+	}
+	res = ts.upload(u, "mutasi.ofx", readSample(t, "mutasi.ofx"), opts)
+	if res.Code != http.StatusOK || num(res.Body["imported"]) != 0 || num(res.Body["already_imported"]) != 4 {
+		t.Fatalf("import OFX ulang: %d %s", res.Code, res.Raw)
+	}
+	if got := ts.saldo(u); got != 8500000-125500-350000-45000 {
+		t.Fatalf("saldo %v", got)
+	}
+}
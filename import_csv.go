@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Import CSV: mapping kolom
+// ==========================
+//
+// Opsi (field form selain "file"):
+//
+//	mapping            JSON {"date":"Tanggal","amount":"Nominal","type":"Jenis",...}
+//	                   nilai = nama header (tidak case-sensitive) atau nomor kolom (1, 2, ...)
+//	has_header         default true
+//	delimiter          auto (default), comma, semicolon, tab, pipe
+//	date_format        auto (default, sama seperti occurred_at), DD/MM/YYYY,
+//	                   MM/DD/YYYY, DD-MM-YYYY, DD.MM.YYYY
+//	decimal_separator  "." (default) atau ","
+//
+// Field mapping: date (wajib), amount atau income/expense (wajib), type,
+// category, note, user, tags. Tanpa type, jenis diambil dari tanda amount
+// (negatif = Pengeluaran) atau dari kolom income/expense yang terisi.
+// Tanpa mapping, header dicocokkan dengan nama kolom export CSV
+// (export.go), jadi hasil export bisa langsung diimport kembali.
+
+var importFields = []string{"date", "amount", "type", "income", "expense", "category", "note", "user", "tags"}
+
+// importHeaderAliases dipakai jika client tidak mengirim mapping.
+var importHeaderAliases = map[string][]string{
+	"date":     {"tanggal", "date", "occurred_at"},
+	"amount":   {"nominal", "amount", "jumlah"},
+	"type":     {"jenis", "type"},
+	"income":   {"pemasukan", "income"},
+	"expense":  {"pengeluaran", "expense"},
+	"category": {"kategori", "category"},
+	"note":     {"keterangan", "note", "catatan"},
+	"user":     {"user", "anggota"},
+	"tags":     {"tags", "tag"},
+}
+
+var importDateFormats = map[string]string{
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+	"DD-MM-YYYY": "02-01-2006",
+	"DD.MM.YYYY": "02.01.2006",
+}
+
+func invalidMapping(msg string) error {
+	return clientError(http.StatusBadRequest, "invalid_mapping", msg)
+}
+
+// detectDelimiter memilih delimiter yang paling sering muncul di baris pertama.
+func detectDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', 0
+	for _, d := range []rune{',', ';', '\t', '|'} {
+		if n := bytes.Count(line, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// csvPlain membuang tanda ' yang ditambahkan csvText saat export.
+func csvPlain(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// parseImportAmount membaca nominal dari spreadsheet: "Rp 12.500",
+// "-1,250.50", "(500)" dan sejenisnya.
+func parseImportAmount(s, decimalSep string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		neg, s = true, s[1:len(s)-1]
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-':
+			neg = !neg
+		case string(r) == decimalSep:
+			b.WriteByte('.')
+		}
+	}
+	m, err := parseMoney(b.String())
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// parseCSVImport mengubah file CSV menjadi importRow sesuai opsi di r.
+// Kesalahan per baris dicatat di importRow.Errors; error yang dikembalikan
+// hanya untuk file/mapping yang tidak bisa dibaca sama sekali.
+func parseCSVImport(data []byte, r *http.Request, tz *time.Location) ([]importRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM dari Excel
+
+	hasHeader := true
+	if v := r.FormValue("has_header"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, invalidMapping("has_header harus true atau false")
+		}
+		hasHeader = b
+	}
+	delimiter := detectDelimiter(data)
+	if v := strings.ToLower(r.FormValue("delimiter")); v != "" && v != "auto" {
+		d, ok := exportDelimiters[v]
+		if !ok {
+			return nil, invalidMapping("delimiter harus auto, comma, semicolon, tab atau pipe")
+		}
+		delimiter = d
+	}
+	dateLayout := ""
+	if v := strings.ToUpper(r.FormValue("date_format")); v != "" && v != "AUTO" {
+		layout, ok := importDateFormats[v]
+		if !ok {
+			return nil, invalidMapping("date_format tidak dikenal: " + v)
+		}
+		dateLayout = layout
+	}
+	decimalSep := "."
+	switch v := r.FormValue("decimal_separator"); v {
+	case "", ".":
+	case ",":
+		decimalSep = ","
+	default:
+		return nil, invalidMapping(`decimal_separator harus "." atau ","`)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var header []string
+	if hasHeader {
+		var err error
+		if header, err = reader.Read(); err != nil {
+			return nil, invalidMapping("Header CSV tidak bisa dibaca")
+		}
+	}
+	columns, err := importColumns(r.FormValue("mapping"), header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, invalidMapping("CSV tidak valid: " + err.Error())
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) >= maxImportRows {
+			rows = append(rows, importRow{Line: line}) // cukup untuk ditolak runImport
+			break
+		}
+		rows = append(rows, csvImportRow(record, line, columns, tz, dateLayout, decimalSep))
+	}
+	return rows, nil
+}
+
+// importColumns menerjemahkan mapping menjadi indeks kolom (0-based).
+func importColumns(raw string, header []string) (map[string]int, error) {
+	mapping := map[string]string{}
+	if raw != "" {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &m); err != nil {
+			return nil, invalidMapping("mapping harus objek JSON")
+		}
+		for field, v := range m {
+			mapping[field] = strings.TrimSpace(fmt.Sprint(v))
+		}
+	} else {
+		for field, aliases := range importHeaderAliases {
+			for _, h := range header {
+				for _, a := range aliases {
+					if strings.EqualFold(strings.TrimSpace(h), a) && mapping[field] == "" {
+						mapping[field] = h
+					}
+				}
+			}
+		}
+	}
+
+	columns := map[string]int{}
+	for field, ref := range mapping {
+		known := false
+		for _, f := range importFields {
+			known = known || f == field
+		}
+		if !known {
+			return nil, invalidMapping("Field mapping tidak dikenal: " + field)
+		}
+		if ref == "" {
+			continue
+		}
+		idx := -1
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), ref) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			if n, err := strconv.Atoi(ref); err == nil && n >= 1 {
+				idx = n - 1
+			}
+		}
+		if idx < 0 {
+			return nil, invalidMapping(fmt.Sprintf("Kolom %q untuk %s tidak ditemukan", ref, field))
+		}
+		columns[field] = idx
+	}
+
+	if _, ok := columns["date"]; !ok {
+		return nil, invalidMapping("mapping date wajib diisi")
+	}
+	_, amount := columns["amount"]
+	_, income := columns["income"]
+	_, expense := columns["expense"]
+	if !amount && !income && !expense {
+		return nil, invalidMapping("mapping amount atau income/expense wajib diisi")
+	}
+	return columns, nil
+}
+
+func csvImportRow(record []string, line int, columns map[string]int, tz *time.Location, dateLayout, decimalSep string) importRow {
+	row := importRow{Line: line, Tags: []string{}}
+	get := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	if v := get("date"); v != "" {
+		var err error
+		if dateLayout != "" {
+			row.OccurredAt, err = time.ParseInLocation(dateLayout, v, tz)
+		} else {
+			row.OccurredAt, err = parseOccurredAt(v, tz)
+		}
+		if err != nil {
+			row.fail("occurred_at", "tanggal tidak valid: "+v)
+		}
+	}
+
+	row.Jenis = get("type")
+	amount := func(field string) Money {
+		v := get(field)
+		if v == "" {
+			return 0
+		}
+		m, err := parseImportAmount(v, decimalSep)
+		if err != nil {
+			row.fail("nominal", "nominal tidak valid: "+v)
+		}
+		return m
+	}
+	if _, ok := columns["amount"]; ok {
+		row.Nominal = amount("amount")
+		if row.Nominal < 0 {
+			row.Nominal = -row.Nominal
+			if row.Jenis == "" {
+				row.Jenis = "Pengeluaran"
+			}
+		} else if row.Jenis == "" {
+			row.Jenis = "Pemasukan"
+		}
+	} else {
+		income, expense := amount("income"), amount("expense")
+		switch {
+		case income != 0 && expense != 0:
+			row.fail("nominal", "pemasukan dan pengeluaran tidak boleh terisi bersamaan")
+		case expense != 0:
+			row.Nominal = expense
+			if row.Jenis == "" {
+				row.Jenis = "Pengeluaran"
+			}
+		default:
+			row.Nominal = income
+			if row.Jenis == "" {
+				row.Jenis = "Pemasukan"
+			}
+		}
+		if row.Nominal < 0 {
+			row.Nominal = -row.Nominal
+		}
+	}
+
+	row.Kategori = csvPlain(get("category"))
+	row.Keterangan = csvPlain(get("note"))
+	row.UserName = csvPlain(get("user"))
+	if v := csvPlain(get("tags")); v != "" {
+		row.Tags = strings.Split(v, ",")
+	}
+	return row
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// upload mengirim file ke /transaksi/import sebagai multipart/form-data.
+// Boundary dan urutan field dibuat tetap supaya request yang sama
+// menghasilkan body yang sama (untuk Idempotency-Key).
+func (ts *testServer) upload(u testUser, filename string, data []byte, fields map[string]string, headers ...string) *httpResponse {
+	ts.t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.SetBoundary("batas-upload-test")
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		mw.WriteField(k, fields[k])
+	}
+	fw, _ := mw.CreateFormFile("file", filename)
+	fw.Write(data)
+	mw.Close()
+
+	rec := ts.request("POST", "/transaksi/import", u.Token, buf.Bytes(), append(headers, "Content-Type", mw.FormDataContentType())...)
+	return &httpResponse{Code: rec.Code, Body: decodeJSON(ts.t, rec), Raw: rec.Body.String()}
+}
+
+type httpResponse struct {
+	Code int
+	Body map[string]interface{}
+	Raw  string
+}
+
+const importCSV = `Tanggal,Jenis,Nominal,Kategori,Keterangan,Tags
+2026-03-01,Pemasukan,100000,Lainnya,Gaji,
+2026-03-02,Pengeluaran,"12,500",Makanan,Bakso,"makan, malam"
+2026-03-03,Pengeluaran,abc,Makanan,Rusak,
+`
+
+func TestImportCSV(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+
+	// 🔹 Default dry run: tidak ada yang tersimpan
+	res := ts.upload(u, "mutasi.csv", []byte(importCSV), nil)
+	if res.Code != http.StatusOK || res.Body["dry_run"] != true || num(res.Body["valid"]) != 2 || num(res.Body["invalid"]) != 1 {
+		t.Fatalf("preview: %d %s", res.Code, res.Raw)
+	}
+	rows := res.Body["rows"].([]interface{})
+	bad := rows[2].(map[string]interface{})
+	if num(bad["baris"]) != 4 || bad["errors"].([]interface{})[0].(map[string]interface{})["field"] != "nominal" {
+		t.Fatalf("baris tidak valid: %v", bad)
+	}
+	if got := ts.saldo(u); got != 0 {
+		t.Fatalf("dry run mengubah saldo: %v", got)
+	}
+
+	res = ts.upload(u, "mutasi.csv", []byte(importCSV), map[string]string{"dry_run": "false"})
+	if res.Code != http.StatusUnprocessableEntity || res.Body["code"] != "invalid_rows" {
+		t.Fatalf("commit dengan baris rusak: %d %s", res.Code, res.Raw)
+	}
+
+	res = ts.upload(u, "mutasi.csv", []byte(importCSV), map[string]string{"dry_run": "false", "skip_invalid": "true"}, "Idempotency-Key", "import-1")
+	if res.Code != http.StatusOK || num(res.Body["imported"]) != 2 || num(res.Body["total_saldo"]) != 87500 {
+		t.Fatalf("commit: %d %s", res.Code, res.Raw)
+	}
+	if again := ts.upload(u, "mutasi.csv", []byte(importCSV), map[string]string{"dry_run": "false", "skip_invalid": "true"}, "Idempotency-Key", "import-1"); again.Raw != res.Raw {
+		t.Fatalf("replay import berbeda: %s", again.Raw)
+	}
+
+	// 🔹 Import ulang: baris yang sama ditandai duplikat dan dilewati
+	res = ts.upload(u, "mutasi.csv", []byte(importCSV), map[string]string{"dry_run": "false", "skip_invalid": "true"})
+	if res.Code != http.StatusOK || num(res.Body["imported"]) != 0 || num(res.Body["skipped_duplicates"]) != 2 {
+		t.Fatalf("import ulang: %d %s", res.Code, res.Raw)
+	}
+
+	list := ts.expect(http.StatusOK, "GET", "/get-transaksi?tags=makan", u.Token, nil)
+	if num(list["total"]) != 1 {
+		t.Fatalf("tag hasil import: %v", list)
+	}
+
+	mapping := `{"date":1,"amount":2,"note":3}`
+	res = ts.upload(u, "bank.csv", []byte("05/03/2026;-7.500,00;Parkir\n"), map[string]string{
+		"mapping": mapping, "has_header": "false", "date_format": "DD/MM/YYYY", "decimal_separator": ",", "default_category": "Lainnya",
+	})
+	row := res.Body["rows"].([]interface{})[0].(map[string]interface{})
+	if res.Code != http.StatusOK || row["jenis"] != "Pengeluaran" || num(row["nominal"]) != 7500 {
+		t.Fatalf("mapping kolom: %d %s", res.Code, res.Raw)
+	}
+
+	for name, c := range map[string]struct {
+		fields map[string]string
+		code   string
+	}{
+		"mapping tanpa date":   {map[string]string{"mapping": `{"amount":"Nominal"}`}, "invalid_mapping"},
+		"format tidak dikenal": {map[string]string{"format": "xls"}, "invalid_format"},
+		"dry_run bukan bool":   {map[string]string{"dry_run": "mungkin"}, "invalid_option"},
+	} {
+		if res := ts.upload(u, "mutasi.csv", []byte(importCSV), c.fields); res.Code != http.StatusBadRequest || res.Body["code"] != c.code {
+			t.Fatalf("%s: %d %s", name, res.Code, res.Raw)
+		}
+	}
+	if rec := ts.request("POST", "/transaksi/import", u.Token, map[string]interface{}{"file": "x"}); rec.Code != http.StatusBadRequest || errorCode(t, rec) != "invalid_upload" {
+		t.Fatalf("bukan multipart: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// Body upload lebih besar dari maxPeekBody (batas middleware yang
+// mengintip body JSON) tetap sampai utuh ke handler import.
+func TestImportUploadBesar(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+
+	for _, size := range []int{maxPeekBody + maxPeekBody/2, 4 << 20} {
+		// Baris kosong dilewati parser CSV, jadi hanya 2 transaksi.
+		data := append([]byte(importCSV[:strings.Index(importCSV, "2026-03-03")]), bytes.Repeat([]byte("\n"), size)...)
+		key := fmt.Sprintf("besar-%d", size)
+
+		res := ts.upload(u, "mutasi.csv", data, map[string]string{"dry_run": "false", "include_duplicates": "true"}, "Idempotency-Key", key)
+		if res.Code != http.StatusOK || num(res.Body["imported"]) != 2 {
+			t.Fatalf("upload %d byte: %d %s", len(data), res.Code, res.Raw)
+		}
+		if again := ts.upload(u, "mutasi.csv", data, map[string]string{"dry_run": "false", "include_duplicates": "true"}, "Idempotency-Key", key); again.Raw != res.Raw {
+			t.Fatalf("replay upload %d byte: %d %s", len(data), again.Code, again.Raw)
+		}
+	}
+	if got := ts.saldo(u); got != 2*87500 {
+		t.Fatalf("saldo %v, mau %v", got, 2*87500)
+	}
+
+	data := bytes.Repeat([]byte("\n"), maxImportRequest)
+	if res := ts.upload(u, "mutasi.csv", data, nil, "Idempotency-Key", "terlalu-besar"); res.Code != http.StatusRequestEntityTooLarge || res.Body["code"] != "request_too_large" {
+		t.Fatalf("upload melebihi batas: %d %s", res.Code, res.Raw)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ==========================
+// 🔹 Saldo room (room_balance)
+// ==========================
+//
+// Setiap perubahan di tabel transactions wajib mengubah room_balance di
+// dalam DB transaction yang sama, sehingga total_saldo selalu sama dengan
+// jumlah seluruh ledger room tsb.
+//
+// Urutan penguncian: baris transaksi (jika ada) lebih dulu, baru
+// room_balance. Jangan dibalik supaya tidak terjadi deadlock.
+
+// lockRoomBalance mengunci baris room_balance milik room (FOR UPDATE) dan
+// mengembalikan saldo saat ini. Baris dibuat dengan saldo 0 jika belum ada.
+// tx harus Store yang didapat dari WithTx.
+func lockRoomBalance(ctx context.Context, tx Store, roomID int) (Money, error) {
+	saldo, err := tx.Balances().Lock(ctx, roomID)
+	if errors.Is(err, ErrNotFound) {
+		if err := tx.Balances().Create(ctx, roomID, 0); err != nil {
+			return 0, fmt.Errorf("gagal membuat saldo baru: %w", err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("gagal membaca saldo: %w", err)
+	}
+	return saldo, nil
+}
+
+// setRoomBalance menyimpan saldo baru; baris harus sudah dikunci lewat
+// lockRoomBalance di transaksi yang sama.
+func setRoomBalance(ctx context.Context, tx Store, roomID int, saldo Money) error {
+	if err := tx.Balances().Set(ctx, roomID, saldo); err != nil {
+		return fmt.Errorf("gagal update saldo: %w", err)
+	}
+	return nil
+}
+
+// addRoomBalance mengunci saldo room lalu menambahkan delta (boleh
+// negatif) dan mengembalikan saldo baru.
+func addRoomBalance(ctx context.Context, tx Store, roomID int, delta Money) (Money, error) {
+	saldo, err := lockRoomBalance(ctx, tx, roomID)
+	if err != nil {
+		return 0, err
+	}
+	saldo += delta
+	if err := setRoomBalance(ctx, tx, roomID, saldo); err != nil {
+		return 0, err
+	}
+	return saldo, nil
+}
+
+// guardNegativeBalance menolak saldo baru yang negatif jika room
+// mengaktifkan aturan forbid_negative_balance. Dipanggil sebelum transaksi
+// DB di-commit, sehingga penolakan ikut membatalkan seluruh perubahan.
+func guardNegativeBalance(ctx context.Context, tx Store, roomID int, saldo Money) error {
+	if saldo >= 0 {
+		return nil
+	}
+	room, err := tx.Rooms().Get(ctx, roomID)
+	if err != nil {
+		return fmt.Errorf("gagal membaca aturan room: %w", err)
+	}
+	if room.ForbidNegativeBalance {
+		return clientError(http.StatusConflict, "negative_balance",
+			fmt.Sprintf("Saldo room tidak boleh negatif (saldo akan menjadi %s)", saldo))
+	}
+	return nil
+}
+
+// jenisSign mengembalikan +1 untuk Pemasukan dan -1 untuk Pengeluaran.
+func jenisSign(jenis string) Money {
+	if jenis == "Pengeluaran" {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Pagination, urutan & filter listing transaksi
+// ==========================
+//
+// Dipakai GET /transaksi (/get-transaksi) dan /seluruh-transaksi:
+//
+//	?limit=50&cursor=...          halaman (cursor = next_cursor respon sebelumnya)
+//	?sort=date|amount&order=desc|asc
+//	?from=2026-01-01&to=2026-01-31 rentang occurred_at di zona waktu room
+//	?jenis=&kategori=|category_id=&user_id=
+//	?min_nominal=&max_nominal=&q=teks
+//	?tags=a,b&tag_mode=any|all
+//
+// Cursor menyimpan posisi baris terakhir (keyset), jadi halaman berikutnya
+// tetap benar walaupun ada transaksi baru di antaranya. Filter kategori
+// ikut menyertakan sub-kategorinya.
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// pageCursor adalah isi next_cursor. Sort dan Asc ikut disimpan supaya
+// cursor tidak dipakai dengan urutan yang berbeda.
+type pageCursor struct {
+	Sort LedgerSort `json:"s"`
+	Asc  bool       `json:"a,omitempty"`
+	LedgerCursor
+}
+
+func encodeCursor(f LedgerFilter, t Transaction) string {
+	b, _ := json.Marshal(pageCursor{
+		Sort:         f.SortBy,
+		Asc:          f.Ascending,
+		LedgerCursor: LedgerCursor{OccurredAt: t.OccurredAt, Nominal: t.Nominal, ID: t.ID},
+	})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(f LedgerFilter, s string) (*LedgerCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if c.Sort != f.SortBy || c.Asc != f.Ascending || c.ID <= 0 {
+		return nil, errors.New("cursor tidak cocok dengan sort/order")
+	}
+	return &c.LedgerCursor, nil
+}
+
+// readLedgerQuery membaca filter, urutan dan pagination dari query string.
+// Input salah menjadi error 400 invalid_filter (lihat writeTxError).
+func (s *Server) readLedgerQuery(ctx context.Context, r *http.Request, roomID int) (LedgerFilter, error) {
+	q := r.URL.Query()
+	f := LedgerFilter{RoomID: roomID, SortBy: SortByDate, Limit: defaultPageLimit}
+	invalid := func(msg string) (LedgerFilter, error) {
+		return f, clientError(http.StatusBadRequest, "invalid_filter", msg)
+	}
+
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return invalid("user_id tidak valid")
+		}
+		f.UserID = id
+	}
+	if v := q.Get("jenis"); v != "" {
+		f.Jenis = normalizeLabel(v)
+		if !validJenis[f.Jenis] {
+			return invalid("jenis harus Pemasukan atau Pengeluaran")
+		}
+	}
+
+	// 🔹 Kategori (beserta sub-kategori), termasuk yang sudah diarsipkan
+	if q.Get("category_id") != "" || q.Get("kategori") != "" {
+		var (
+			c   Category
+			err error
+		)
+		if v := q.Get("category_id"); v != "" {
+			id, convErr := strconv.Atoi(v)
+			if convErr != nil {
+				return invalid("category_id tidak valid")
+			}
+			c, err = s.store.Categories().Get(ctx, id)
+			if err == nil && c.RoomID != roomID {
+				err = ErrNotFound
+			}
+		} else {
+			c, err = s.store.Categories().FindByName(ctx, roomID, strings.TrimSpace(q.Get("kategori")))
+		}
+		if errors.Is(err, ErrNotFound) {
+			return invalid("Kategori tidak ditemukan di room ini")
+		}
+		if err != nil {
+			return f, fmt.Errorf("Gagal membaca kategori: %w", err)
+		}
+		all, err := s.store.Categories().List(ctx, roomID, true)
+		if err != nil {
+			return f, fmt.Errorf("Gagal membaca kategori: %w", err)
+		}
+		f.CategoryIDs = []int{c.ID}
+		for _, sub := range all {
+			if sub.ParentID == c.ID {
+				f.CategoryIDs = append(f.CategoryIDs, sub.ID)
+			}
+		}
+	}
+
+	// 🔹 Rentang tanggal; "to" tanpa jam berarti sampai akhir hari itu
+	if q.Get("from") != "" || q.Get("to") != "" {
+		room, err := s.store.Rooms().Get(ctx, roomID)
+		if err != nil {
+			return f, fmt.Errorf("Gagal membaca room: %w", err)
+		}
+		tz := roomLocation(room)
+		if v := q.Get("from"); v != "" {
+			if f.From, err = parseOccurredAt(v, tz); err != nil {
+				return invalid("from: " + err.Error())
+			}
+		}
+		if v := q.Get("to"); v != "" {
+			if f.To, err = parseOccurredAt(v, tz); err != nil {
+				return invalid("to: " + err.Error())
+			}
+			if _, err := time.Parse("2006-01-02", strings.TrimSpace(v)); err == nil {
+				f.To = f.To.AddDate(0, 0, 1)
+			} else {
+				f.To = f.To.Add(time.Nanosecond)
+			}
+		}
+		if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+			return invalid("from harus sebelum to")
+		}
+	}
+
+	for name, dst := range map[string]*Money{"min_nominal": &f.MinNominal, "max_nominal": &f.MaxNominal} {
+		if v := q.Get(name); v != "" {
+			m, err := parseMoney(v)
+			if err != nil || m <= 0 {
+				return invalid(name + " harus angka lebih dari 0")
+			}
+			*dst = m
+		}
+	}
+	if f.MinNominal != 0 && f.MaxNominal != 0 && f.MinNominal > f.MaxNominal {
+		return invalid("min_nominal tidak boleh lebih besar dari max_nominal")
+	}
+	f.Query = strings.TrimSpace(q.Get("q"))
+
+	if msg := readTagFilter(r, &f); msg != "" {
+		return invalid(msg)
+	}
+
+	// 🔹 Urutan & pagination
+	switch LedgerSort(q.Get("sort")) {
+	case "", SortByDate:
+	case SortByAmount:
+		f.SortBy = SortByAmount
+	default:
+		return invalid("sort harus date atau amount")
+	}
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return invalid("order harus asc atau desc")
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			return invalid(fmt.Sprintf("limit harus antara 1 dan %d", maxPageLimit))
+		}
+		f.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		after, err := decodeCursor(f, v)
+		if err != nil {
+			return invalid("cursor tidak valid")
+		}
+		f.After = after
+	}
+	return f, nil
+}
+
+// listLedgerPage mengambil satu halaman transaksi sesuai f, jumlah seluruh
+// transaksi yang cocok dengan filter, dan cursor halaman berikutnya ("" jika
+// sudah halaman terakhir).
+func (s *Server) listLedgerPage(ctx context.Context, f LedgerFilter) ([]Transaction, int, string, error) {
+	totals, err := s.store.Transactions().Sum(ctx, f)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	limit := f.Limit
+	f.Limit = limit + 1 // satu baris ekstra untuk tahu masih ada halaman berikutnya
+	rows, err := s.store.Transactions().List(ctx, f)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	if rows == nil {
+		rows = []Transaction{}
+	}
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = encodeCursor(f, rows[limit-1])
+	}
+	return rows, totals.Count, next, nil
+}
+
+// nullableCursor menulis cursor kosong sebagai null di JSON.
+func nullableCursor(next string) interface{} {
+	if next == "" {
+		return nil
+	}
+	return next
+}
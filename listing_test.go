@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// seedLedger mengisi room u dengan transaksi 1..n Maret 2026, nominal
+// kelipatan 1000; yang genap Pengeluaran.
+func seedLedger(ts *testServer, u testUser, n int) {
+	ts.t.Helper()
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 1000000, "occurred_at": "2026-02-28"})
+	for i := 1; i <= n; i++ {
+		jenis, kategori := "Pemasukan", "Lainnya"
+		if i%2 == 0 {
+			jenis, kategori = "Pengeluaran", "Makanan"
+		}
+		ts.addTx(u, map[string]interface{}{
+			"jenis": jenis, "kategori": kategori, "nominal": i * 1000,
+			"occurred_at": fmt.Sprintf("2026-03-%02d 10:00", i), "keterangan": fmt.Sprintf("transaksi ke-%d", i),
+		})
+	}
+}
+
+// pageAll mengikuti next_cursor sampai habis dan mengembalikan semua item.
+func pageAll(ts *testServer, u testUser, path, query, field string) []map[string]interface{} {
+	ts.t.Helper()
+	var all []map[string]interface{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 50 {
+			ts.t.Fatalf("cursor tidak pernah habis")
+		}
+		q := query
+		if cursor != "" {
+			q += "&cursor=" + url.QueryEscape(cursor)
+		}
+		out := ts.expect(http.StatusOK, "GET", path+"?"+q, u.Token, nil)
+		for _, item := range out[field].([]interface{}) {
+			all = append(all, item.(map[string]interface{}))
+		}
+		next, _ := out["next_cursor"].(string)
+		if next == "" {
+			return all
+		}
+		cursor = next
+	}
+}
+
+func TestListingCursor(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	seedLedger(ts, u, 7)
+
+	first := ts.expect(http.StatusOK, "GET", "/get-transaksi?limit=3", u.Token, nil)
+	if num(first["total"]) != 8 || num(first["total_data"]) != 3 || first["next_cursor"] == nil {
+		t.Fatalf("halaman pertama: %v", first)
+	}
+
+	all := pageAll(ts, u, "/get-transaksi", "limit=3", "transaksi")
+	if len(all) != 8 {
+		t.Fatalf("jumlah seluruh halaman %d, mau 8", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1]["occurred_at"].(string) < all[i]["occurred_at"].(string) {
+			t.Fatalf("urutan bukan terbaru dulu di %d: %v", i, all)
+		}
+	}
+
+	byAmount := pageAll(ts, u, "/get-transaksi", "limit=2&sort=amount&order=asc&jenis=Pengeluaran", "transaksi")
+	var got []float64
+	for _, t := range byAmount {
+		got = append(got, num(t["nominal"]))
+	}
+	if fmt.Sprint(got) != "[2000 4000 6000]" {
+		t.Fatalf("sort=amount&order=asc&jenis=Pengeluaran: %v", got)
+	}
+
+	legacy := pageAll(ts, u, "/seluruh-transaksi", "limit=5&from=2026-03-02&to=2026-03-04", "data")
+	if len(legacy) != 3 {
+		t.Fatalf("seluruh-transaksi from/to: %d baris", len(legacy))
+	}
+
+	filtered := ts.expect(http.StatusOK, "GET", "/get-transaksi?kategori=makanan&min_nominal=3000&q=ke-6", u.Token, nil)
+	if num(filtered["total"]) != 1 || filtered["next_cursor"] != nil {
+		t.Fatalf("filter gabungan: %v", filtered)
+	}
+
+	// 🔹 Cursor hanya berlaku untuk sort/order yang sama
+	cursor := first["next_cursor"].(string)
+	for _, q := range []string{
+		"cursor=bukan-cursor",
+		"sort=amount&cursor=" + url.QueryEscape(cursor),
+		"limit=0",
+		"sort=nama",
+		"jenis=Hibah",
+		"from=2026-03-05&to=2026-03-01",
+		"min_nominal=5000&max_nominal=1000",
+	} {
+		rec := ts.request("GET", "/get-transaksi?"+q, u.Token, nil)
+		if rec.Code != http.StatusBadRequest || errorCode(t, rec) != "invalid_filter" {
+			t.Fatalf("%s: status %d %s", q, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -1,28 +1,107 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 )
 
+// Server menyimpan dependensi yang dipakai semua handler. Storage
+// disuntikkan lewat NewServer: pgStore di production, memoryStore untuk
+// test.
+type Server struct {
+	store Store
+}
+
+func NewServer(store Store) *Server {
+	return &Server{store: store}
+}
+
+// routes adalah tabel routing aplikasi beserta aturan aksesnya.
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/register", s.RegisterHandler)
+	mux.HandleFunc("/login", s.LoginHandler)
+	mux.HandleFunc("/refresh-token", s.RefreshTokenHandler)
+	mux.HandleFunc("/logout", s.requireAuth(s.LogoutHandler))
+	mux.HandleFunc("/rooms", s.requireAuth(s.RoomsHandler))
+	mux.HandleFunc("/rooms/invite", s.requireRoom(s.CreateInviteHandler))
+	mux.HandleFunc("/rooms/aktivitas", s.requireRoom(s.GetAktivitasRoom))
+	mux.HandleFunc("/rooms/laporan", s.requireRoom(s.LaporanBulanan))
+	mux.HandleFunc("/rooms/join", s.requireAuth(s.JoinRoomHandler))
+	mux.HandleFunc("/pemasukan", s.requireRoom(s.idempotent(s.Pemasukan)))
+	mux.HandleFunc("/pengeluaran", s.requireRoom(s.idempotent(s.Pengeluaran)))
+	mux.HandleFunc("/transaksi", s.requireRoom(s.idempotent(s.TransaksiHandler)))
+	mux.HandleFunc("/transaksi/sampah", s.requireRoom(s.GetSampahTransaksi))
+	mux.HandleFunc("/transaksi/pulihkan", s.requireTransaction("trash", false, s.idempotent(s.PulihkanTransaksi)))
+	mux.HandleFunc("/transaksi/riwayat", s.requireRoom(s.GetRiwayatTransaksi))
+	mux.HandleFunc("/transaksi/cari", s.requireRoom(s.CariTransaksi))
+	mux.HandleFunc("/transaksi/export", s.requireRoom(s.ExportTransaksi))
+	mux.HandleFunc("/transaksi/import", s.requireRoom(s.idempotentBody(maxImportRequest, s.ImportTransaksi)))
+	mux.HandleFunc("/kategori", s.requireRoom(s.KategoriHandler))
+	mux.HandleFunc("/tag", s.requireRoom(s.GetTag))
+	mux.HandleFunc("/transaksi-lainnya", s.requireRoom(s.idempotent(s.TambahTransaksi)))
+	mux.HandleFunc("/get-transaksi", s.requireRoom(s.GetTransaksi))
+	mux.HandleFunc("/edit-transaksi-user", s.requireTransaction("user_transactions", true, s.EditTransaksiUserByID))
+	mux.HandleFunc("/edit-transaksi-lainnya", s.requireTransaction("other_transaction", false, s.EditTransaksi))
+	mux.HandleFunc("/hapus-transaksi-user", s.requireTransaction("user_transactions", true, s.HapusTransaksiByID))
+	mux.HandleFunc("/hapus-transaksi-lainnya", s.requireTransaction("other_transaction", false, s.HapusTransaksi))
+	mux.HandleFunc("/seluruh-transaksi", s.requireRoom(s.GetAllTransactionsByRoom))
+	mux.HandleFunc("/admin/reconcile", requireAdmin(s.ReconcileHandler))
+	return mux
+}
+
 func main() {
-	InitDB()
+	conf, err := loadConfig()
+	if err != nil {
+		log.Fatalf("❌ Konfigurasi tidak valid:\n%v\n", err)
+	}
+	applyConfig(conf)
+
+	InitDB(conf)
 	defer DB.Close()
 
-	http.HandleFunc("/register", RegisterHandler)
-    http.HandleFunc("/login", LoginHandler)
-    http.HandleFunc("/rooms", GetRoomsHandler)
-	http.HandleFunc("/pemasukan", Pemasukan)
-    http.HandleFunc("/pengeluaran", Pengeluaran)
-	http.HandleFunc("/transaksi-lainnya", TambahTransaksi)
-	http.HandleFunc("/get-transaksi", GetTransaksi)
-	http.HandleFunc("/edit-transaksi-user", EditTransaksiUserByID)
-	http.HandleFunc("/edit-transaksi-lainnya", EditOtherTransaksiByID)
-	http.HandleFunc("/hapus-transaksi-user", HapusTransaksiByID)
-	http.HandleFunc("/seluruh-transaksi", GetAllTransactionsByRoom)
-
-	port := 8080
-	fmt.Printf("🚀 Server berjalan di http://localhost:%d\n", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	srv := NewServer(newPgStore(DB))
+
+	// 🔹 Subcommand CLI (contoh: ./backend reconcile -room 3)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrateCommand(os.Args[2:]); err != nil {
+				log.Fatalf("❌ Migrasi gagal: %v\n", err)
+			}
+			return
+		case "reconcile":
+			if err := runReconcileCommand(srv.store, os.Args[2:]); err != nil {
+				log.Fatalf("❌ Rekonsiliasi gagal: %v\n", err)
+			}
+			return
+		default:
+			log.Fatalf("❌ Perintah tidak dikenal: %s\n", os.Args[1])
+		}
+	}
+
+	// 🔹 Pastikan skema database sudah versi terbaru
+	if conf.AutoMigrate {
+		done, err := migrateUp(context.Background(), 0)
+		if err != nil {
+			log.Fatalf("❌ Migrasi database gagal: %v\n", err)
+		}
+		for _, m := range done {
+			fmt.Printf("⬆️  Migrasi %04d_%s diterapkan\n", m.Version, m.Name)
+		}
+	} else if pending, err := pendingMigrations(context.Background()); err != nil {
+		log.Fatalf("❌ Gagal memeriksa migrasi: %v\n", err)
+	} else if len(pending) > 0 {
+		log.Printf("⚠️ Ada %d migrasi yang belum dijalankan, jalankan: ./backend migrate up\n", len(pending))
+	}
+
+	// 🔹 Bersihkan tempat sampah transaksi dan Idempotency-Key kedaluwarsa secara berkala
+	startTrashPurger(context.Background(), srv.store, conf.TrashRetention.Duration, conf.TrashPurgeInterval.Duration)
+	startIdempotencyPurger(context.Background(), srv.store, idempotencyPurgeInterval)
+
+	fmt.Printf("🚀 Server berjalan di %s (%s)\n", conf.ListenAddr, conf.Env)
+	log.Fatal(http.ListenAndServe(conf.ListenAddr, withCORS(conf.CORSOrigins, srv.routes())))
 }
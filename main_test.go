@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Semua test memakai NewServer(newMemoryStore()).routes(), jadi berjalan
+// tanpa database.
+
+const testAdminToken = "admin-test-token"
+
+func TestMain(m *testing.M) {
+	c := defaultConfig()
+	c.TokenSecret = "test-secret-yang-cukup-panjang-untuk-hmac"
+	c.AdminToken = testAdminToken
+	applyConfig(c)
+	os.Exit(m.Run())
+}
+
+type testServer struct {
+	t       *testing.T
+	server  *Server
+	handler http.Handler
+	users   int
+}
+
+// testUser adalah user yang sudah register & login.
+type testUser struct {
+	ID    int
+	Name  string
+	Email string
+	Token string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	s := NewServer(newMemoryStore())
+	return &testServer{t: t, server: s, handler: s.routes()}
+}
+
+// request mengirim request ke routes. body boleh nil, string/[]byte (apa
+// adanya) atau nilai lain yang dikirim sebagai JSON. headers berisi
+// pasangan nama, nilai.
+func (ts *testServer) request(method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			ts.t.Fatalf("marshal body: %v", err)
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	ts.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect seperti request tapi menggagalkan test jika status berbeda, lalu
+// mengembalikan body JSON-nya.
+func (ts *testServer) expect(status int, method, path, token string, body interface{}, headers ...string) map[string]interface{} {
+	ts.t.Helper()
+	rec := ts.request(method, path, token, body, headers...)
+	if rec.Code != status {
+		ts.t.Fatalf("%s %s: status %d, mau %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	return decodeJSON(ts.t, rec)
+}
+
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("respon bukan JSON: %v: %s", err, rec.Body.String())
+		}
+	}
+	return out
+}
+
+// errorCode mengambil field code dari respon writeError.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	code, _ := decodeJSON(t, rec)["code"].(string)
+	return code
+}
+
+// num membaca angka dari hasil decode JSON.
+func num(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+func (ts *testServer) register(name string) testUser {
+	ts.t.Helper()
+	ts.users++
+	email := fmt.Sprintf("user%d@example.com", ts.users)
+	ts.expect(http.StatusCreated, "POST", "/register", "", map[string]interface{}{
+		"full_name": name, "email": email, "password": "rahasia123", "confirm_password": "rahasia123",
+	})
+	login := ts.login(testUser{Email: email})
+	return testUser{ID: int(num(login["user_id"])), Name: name, Email: email, Token: login["access_token"].(string)}
+}
+
+func (ts *testServer) login(u testUser) map[string]interface{} {
+	ts.t.Helper()
+	return ts.expect(http.StatusOK, "POST", "/login", "", map[string]interface{}{"identifier": u.Email, "password": "rahasia123"})
+}
+
+// createRoom membuat room milik u; opts menimpa field request.
+func (ts *testServer) createRoom(u testUser, opts map[string]interface{}) int {
+	ts.t.Helper()
+	body := map[string]interface{}{"room_name": "Dompet " + u.Name}
+	for k, v := range opts {
+		body[k] = v
+	}
+	out := ts.expect(http.StatusCreated, "POST", "/rooms", u.Token, body)
+	return int(num(out["room_id"]))
+}
+
+// join memasukkan member ke room owner lewat kode undangan.
+func (ts *testServer) join(owner, member testUser) {
+	ts.t.Helper()
+	invite := ts.expect(http.StatusCreated, "POST", "/rooms/invite", owner.Token, nil)
+	ts.expect(http.StatusOK, "POST", "/rooms/join", member.Token, map[string]interface{}{"invite_code": invite["invite_code"]})
+}
+
+// newRoomUser adalah user baru yang langsung punya room sendiri.
+func (ts *testServer) newRoomUser(name string, opts map[string]interface{}) testUser {
+	ts.t.Helper()
+	u := ts.register(name)
+	ts.createRoom(u, opts)
+	return u
+}
+
+// addTx menambah transaksi lewat POST /transaksi dan mengembalikan id-nya.
+func (ts *testServer) addTx(u testUser, body map[string]interface{}) int {
+	ts.t.Helper()
+	out := ts.expect(http.StatusOK, "POST", "/transaksi", u.Token, body)
+	return int(num(out["id"]))
+}
+
+func (ts *testServer) saldo(u testUser) float64 {
+	ts.t.Helper()
+	return num(ts.login(u)["total_room_saldo"])
+}
+
+// ==========================
+// 🔹 Auth
+// ==========================
+
+func TestAuthFlow(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.register("Andi")
+
+	if rec := ts.request("POST", "/login", "", map[string]interface{}{"identifier": u.Email, "password": "salah"}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("password salah: status %d", rec.Code)
+	}
+	if rec := ts.request("POST", "/register", "", map[string]interface{}{
+		"full_name": "X", "email": "x@example.com", "password": "a", "confirm_password": "b",
+	}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("konfirmasi password beda: status %d", rec.Code)
+	}
+	if rec := ts.request("GET", "/rooms", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("tanpa token: status %d", rec.Code)
+	}
+	if rec := ts.request("GET", "/rooms", "bukan.token.valid", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("token rusak: status %d", rec.Code)
+	}
+
+	// 🔹 Refresh token dirotasi: token lama tidak bisa dipakai dua kali
+	refresh := ts.login(u)["refresh_token"]
+	next := ts.expect(http.StatusOK, "POST", "/refresh-token", "", map[string]interface{}{"refresh_token": refresh})
+	if next["access_token"] == nil || next["refresh_token"] == refresh {
+		t.Fatalf("refresh tidak menghasilkan token baru: %v", next)
+	}
+	if rec := ts.request("POST", "/refresh-token", "", map[string]interface{}{"refresh_token": refresh}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh token lama dipakai ulang: status %d", rec.Code)
+	}
+
+	ts.expect(http.StatusOK, "POST", "/logout", u.Token, map[string]interface{}{"refresh_token": next["refresh_token"]})
+	if rec := ts.request("POST", "/refresh-token", "", map[string]interface{}{"refresh_token": next["refresh_token"]}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh token setelah logout: status %d", rec.Code)
+	}
+}
+
+// ==========================
+// 🔹 Room & keanggotaan
+// ==========================
+
+func TestRoomsInviteJoin(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.register("Andi")
+	partner := ts.register("Bela")
+
+	// 🔹 Belum punya room
+	if rec := ts.request("GET", "/get-transaksi", owner.Token, nil); rec.Code != http.StatusForbidden || errorCode(t, rec) != "no_room" {
+		t.Fatalf("tanpa room: status %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := ts.request("POST", "/rooms", owner.Token, map[string]interface{}{"room_name": "R", "currency": "XYZ"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("mata uang tidak dikenal: status %d", rec.Code)
+	}
+
+	roomID := ts.createRoom(owner, nil)
+	if rec := ts.request("POST", "/rooms", owner.Token, map[string]interface{}{"room_name": "Lagi"}); rec.Code != http.StatusConflict {
+		t.Fatalf("room kedua: status %d", rec.Code)
+	}
+
+	rec := ts.request("GET", "/rooms", owner.Token, nil)
+	var rooms []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rooms); err != nil || len(rooms) != 1 || int(num(rooms[0]["id"])) != roomID {
+		t.Fatalf("GET /rooms: %s", rec.Body.String())
+	}
+
+	if rec := ts.request("POST", "/rooms/join", partner.Token, map[string]interface{}{"invite_code": "SALAH123"}); rec.Code != http.StatusNotFound {
+		t.Fatalf("kode salah: status %d", rec.Code)
+	}
+	ts.join(owner, partner)
+
+	// 🔹 Room penuh setelah dua anggota
+	if rec := ts.request("POST", "/rooms/invite", owner.Token, nil); rec.Code != http.StatusConflict || errorCode(t, rec) != "room_full" {
+		t.Fatalf("undangan room penuh: status %d", rec.Code)
+	}
+
+	// 🔹 Pengaturan room hanya untuk pemilik
+	if rec := ts.request("PATCH", "/rooms", partner.Token, map[string]interface{}{"room_name": "Baru"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("PATCH oleh non-pemilik: status %d", rec.Code)
+	}
+	out := ts.expect(http.StatusOK, "PATCH", "/rooms", owner.Token, map[string]interface{}{"forbid_negative_balance": true})
+	if room := out["room"].(map[string]interface{}); room["forbid_negative_balance"] != true {
+		t.Fatalf("pengaturan tidak tersimpan: %v", out)
+	}
+
+	login := ts.login(partner)
+	if int(num(login["room_id"])) != roomID || len(login["members"].([]interface{})) != 2 {
+		t.Fatalf("login anggota: %v", login)
+	}
+}
+
+func TestNewInviteCode(t *testing.T) {
+	seen := map[rune]int{}
+	for i := 0; i < 2000; i++ {
+		code, err := newInviteCode()
+		if err != nil || len(code) != 8 {
+			t.Fatalf("kode %q: %v", code, err)
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(inviteAlphabet, r) {
+				t.Fatalf("huruf %q di luar inviteAlphabet", r)
+			}
+			seen[r]++
+		}
+	}
+	if len(seen) != len(inviteAlphabet) {
+		t.Fatalf("hanya %d dari %d huruf muncul", len(seen), len(inviteAlphabet))
+	}
+}
+
+func TestRoomMembership(t *testing.T) {
+	ts := newTestServer(t)
+	andi := ts.newRoomUser("Andi", nil)
+	bela := ts.register("Bela")
+	ts.join(andi, bela)
+	caca := ts.newRoomUser("Caca", nil)
+
+	ts.expect(http.StatusOK, "POST", "/pemasukan", andi.Token, map[string]interface{}{"amount": 100000})
+	id := ts.addTx(andi, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 20000})
+	cacaRoom := fmt.Sprint(ts.login(caca)["room_id"])
+	cacaTx := ts.addTx(caca, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 1000})
+	cacaQuery := fmt.Sprintf("?id=%d", cacaTx)
+
+	cases := []struct {
+		name   string
+		user   testUser
+		method string
+		path   string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"room_id query room lain", andi, "GET", "/get-transaksi?room_id=" + cacaRoom, nil, http.StatusForbidden, "room_forbidden"},
+		{"room_id body room lain", andi, "POST", "/transaksi", map[string]interface{}{"room_id": num(cacaRoom), "jenis": "Pemasukan", "kategori": "Makanan", "nominal": 1}, http.StatusForbidden, "room_forbidden"},
+		{"edit transaksi room lain", caca, "PUT", "/transaksi", map[string]interface{}{"id": id, "nominal": 1, "version": 1}, http.StatusForbidden, "room_forbidden"},
+		{"hapus transaksi room lain", caca, "DELETE", "/hapus-transaksi-lainnya", map[string]interface{}{"id": id}, http.StatusForbidden, "room_forbidden"},
+		{"transaksi tidak ada", andi, "PUT", "/edit-transaksi-lainnya", map[string]interface{}{"id": 9999, "nominal": 1, "version": 1}, http.StatusNotFound, "not_found"},
+		{"tanpa id", andi, "DELETE", "/transaksi", map[string]interface{}{}, http.StatusBadRequest, "missing_id"},
+		{"transaksi lama milik anggota lain", bela, "DELETE", "/hapus-transaksi-user", map[string]interface{}{"id": 1}, http.StatusForbidden, "not_owner"},
+		{"pulihkan transaksi yang tidak di sampah", andi, "POST", "/transaksi/pulihkan", map[string]interface{}{"id": id}, http.StatusNotFound, "not_found"},
+		{"id query milik sendiri, id body room lain (edit)", caca, "PUT", "/transaksi" + cacaQuery, map[string]interface{}{"id": id, "nominal": 1, "version": 1}, http.StatusBadRequest, "id_mismatch"},
+		{"id query milik sendiri, id body room lain (hapus)", caca, "DELETE", "/hapus-transaksi-lainnya" + cacaQuery, map[string]interface{}{"id": id}, http.StatusBadRequest, "id_mismatch"},
+		{"id query milik sendiri, id body room lain (pulihkan)", caca, "POST", "/transaksi/pulihkan" + cacaQuery, map[string]interface{}{"id": id}, http.StatusBadRequest, "id_mismatch"},
+		{"GET ?id= room lain", caca, "GET", fmt.Sprintf("/get-transaksi?id=%d", id), nil, http.StatusNotFound, "not_found"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := ts.request(c.method, c.path, c.user.Token, c.body)
+			if rec.Code != c.status || errorCode(t, rec) != c.code {
+				t.Fatalf("status %d (%s), mau %d %s", rec.Code, rec.Body.String(), c.status, c.code)
+			}
+		})
+	}
+
+	// 🔹 Anggota room yang sama boleh mengedit transaksi "lainnya"
+	ts.expect(http.StatusOK, "PUT", "/edit-transaksi-lainnya", bela.Token, map[string]interface{}{"id": id, "nominal": 25000, "version": 1})
+}
+
+func TestAdminReconcile(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	ts.expect(http.StatusOK, "POST", "/pemasukan", u.Token, map[string]interface{}{"amount": 5000})
+
+	if rec := ts.request("GET", "/admin/reconcile", "", nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("tanpa X-Admin-Token: status %d", rec.Code)
+	}
+	out := ts.expect(http.StatusOK, "GET", "/admin/reconcile?all=true", "", nil, "X-Admin-Token", testAdminToken)
+	if out["dry_run"] != true || num(out["total_room"]) != 1 {
+		t.Fatalf("reconcile: %v", out)
+	}
+	if rec := ts.request("GET", "/admin/reconcile?repair=true", "", nil, "X-Admin-Token", testAdminToken); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("repair lewat GET: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Migrasi skema database
+// ==========================
+//
+// File SQL di folder migrations/ ikut di-embed ke binary dengan pola nama
+//
+//	NNNN_nama_migrasi.up.sql
+//	NNNN_nama_migrasi.down.sql
+//
+// Versi yang sudah dijalankan dicatat di tabel schema_migrations. Setiap
+// migrasi berjalan di DB transaction sendiri dengan advisory lock, jadi
+// dua instance server yang start bersamaan tidak menjalankan migrasi yang
+// sama dua kali.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Kunci pg_advisory_xact_lock khusus migrasi (angka bebas, asal tetap).
+const migrationLockKey = 727_001
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, e := range entries {
+		name := e.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("nama file migrasi tidak valid: %s", name)
+		}
+		num, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("nama file migrasi tidak valid: %s", name)
+		}
+
+		body, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("versi migrasi %04d dipakai dua nama: %s dan %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	list := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrasi %04d_%s wajib punya file .up.sql dan .down.sql", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+func ensureMigrationsTable(ctx context.Context) error {
+	_, err := DB.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version     INT PRIMARY KEY,
+			name        TEXT NOT NULL,
+			applied_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	return err
+}
+
+func appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	if err := ensureMigrationsTable(ctx); err != nil {
+		return nil, fmt.Errorf("gagal membuat tabel schema_migrations: %w", err)
+	}
+
+	rows, err := DB.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	return applied, rows.Err()
+}
+
+// migrateUp menjalankan semua migrasi yang belum diterapkan sampai versi
+// target (0 = sampai yang terbaru).
+func migrateUp(ctx context.Context, target int) ([]migration, error) {
+	all, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []migration
+	for _, m := range all {
+		if target != 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		ran, err := runMigration(ctx, m, true)
+		if err != nil {
+			return done, fmt.Errorf("migrasi %04d_%s gagal: %w", m.Version, m.Name, err)
+		}
+		if ran {
+			done = append(done, m)
+		}
+	}
+	return done, nil
+}
+
+// migrateDown membatalkan sejumlah migrasi terakhir.
+func migrateDown(ctx context.Context, steps int) ([]migration, error) {
+	all, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []migration
+	for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		ran, err := runMigration(ctx, m, false)
+		if err != nil {
+			return done, fmt.Errorf("rollback %04d_%s gagal: %w", m.Version, m.Name, err)
+		}
+		if ran {
+			done = append(done, m)
+		}
+	}
+	return done, nil
+}
+
+// runMigration menjalankan satu migrasi di DB transaction sendiri. Status
+// dicek ulang setelah advisory lock didapat; ran bernilai false jika
+// instance lain sudah lebih dulu menjalankannya.
+func runMigration(ctx context.Context, m migration, up bool) (ran bool, err error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	if exists == up {
+		return false, nil
+	}
+
+	if up {
+		if _, err := tx.Exec(ctx, m.Up); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, NOW())`,
+			m.Version, m.Name)
+	} else {
+		if _, err := tx.Exec(ctx, m.Down); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+func pendingMigrations(ctx context.Context) ([]migration, error) {
+	all, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []migration
+	for _, m := range all {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// ==========================
+// 🔹 CLI: backend migrate up|down|status
+// ==========================
+func runMigrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("pemakaian: migrate up [-to N] | down [-steps N] | status")
+	}
+
+	ctx := context.Background()
+	sub, rest := args[0], args[1:]
+
+	switch sub {
+	case "up":
+		flags := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+		to := flags.Int("to", 0, "berhenti di versi ini (0 = terbaru)")
+		if err := flags.Parse(rest); err != nil {
+			return err
+		}
+		done, err := migrateUp(ctx, *to)
+		for _, m := range done {
+			fmt.Printf("⬆️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("✅ Skema sudah versi terbaru")
+		}
+		return nil
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "jumlah migrasi terakhir yang dibatalkan")
+		if err := flags.Parse(rest); err != nil {
+			return err
+		}
+		if *steps <= 0 {
+			return errors.New("-steps harus lebih dari 0")
+		}
+		done, err := migrateDown(ctx, *steps)
+		for _, m := range done {
+			fmt.Printf("⬇️  %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		all, err := loadMigrations()
+		if err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			status := "pending"
+			if at, ok := applied[m.Version]; ok {
+				status = "applied " + at.In(loc).Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", m.Version, m.Name, status)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("subcommand migrate tidak dikenal: %s", sub)
+	}
+}
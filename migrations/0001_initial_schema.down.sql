@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS room_balance;
+DROP TABLE IF EXISTS other_transaction;
+DROP TABLE IF EXISTS user_transactions;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS rooms;
@@ -0,0 +1,44 @@
+-- Skema awal sesuai tabel yang sudah dipakai handler sejak versi pertama.
+-- Memakai IF NOT EXISTS supaya aman dijalankan di database lama yang
+-- tabelnya dibuat manual sebelum ada sistem migrasi.
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id          SERIAL PRIMARY KEY,
+    room_name   TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id             SERIAL PRIMARY KEY,
+    full_name      TEXT NOT NULL,
+    email          TEXT NOT NULL UNIQUE,
+    password_hash  TEXT NOT NULL,
+    room_id        INT REFERENCES rooms(id) ON DELETE SET NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_transactions (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id         INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    pemasukan       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pengeluaran     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tanggal_update  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS other_transaction (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id         INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    jenis           TEXT NOT NULL CHECK (jenis IN ('Pemasukan', 'Pengeluaran')),
+    kategori        TEXT NOT NULL,
+    nominal         DOUBLE PRECISION NOT NULL,
+    keterangan      TEXT NOT NULL DEFAULT '',
+    tanggal_update  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS room_balance (
+    room_id         INT PRIMARY KEY REFERENCES rooms(id) ON DELETE CASCADE,
+    total_saldo     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tanggal_update  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
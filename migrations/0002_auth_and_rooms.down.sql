@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS room_invites;
+ALTER TABLE rooms DROP COLUMN IF EXISTS currency;
+ALTER TABLE rooms DROP COLUMN IF EXISTS owner_id;
+-- users.room_id sengaja tetap nullable: user tanpa room tidak bisa dipulihkan.
+DROP TABLE IF EXISTS refresh_tokens;
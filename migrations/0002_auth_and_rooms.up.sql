@@ -0,0 +1,28 @@
+-- Token sesi, room milik user dan kode undangan.
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash  TEXT NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- User baru belum punya room sampai membuat room atau bergabung lewat undangan.
+ALTER TABLE users ALTER COLUMN room_id DROP NOT NULL;
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS owner_id INT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'IDR';
+
+CREATE TABLE IF NOT EXISTS room_invites (
+    code        TEXT PRIMARY KEY,
+    room_id     INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    created_by  INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_by     INT REFERENCES users(id) ON DELETE SET NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
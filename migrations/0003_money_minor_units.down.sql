@@ -0,0 +1,11 @@
+ALTER TABLE user_transactions ALTER COLUMN pemasukan TYPE DOUBLE PRECISION USING pemasukan::numeric / 100;
+COMMENT ON COLUMN user_transactions.pemasukan IS NULL;
+
+ALTER TABLE user_transactions ALTER COLUMN pengeluaran TYPE DOUBLE PRECISION USING pengeluaran::numeric / 100;
+COMMENT ON COLUMN user_transactions.pengeluaran IS NULL;
+
+ALTER TABLE other_transaction ALTER COLUMN nominal TYPE DOUBLE PRECISION USING nominal::numeric / 100;
+COMMENT ON COLUMN other_transaction.nominal IS NULL;
+
+ALTER TABLE room_balance ALTER COLUMN total_saldo TYPE DOUBLE PRECISION USING total_saldo::numeric / 100;
+COMMENT ON COLUMN room_balance.total_saldo IS NULL;
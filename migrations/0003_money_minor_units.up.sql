@@ -0,0 +1,43 @@
+-- Kolom uang disimpan sebagai BIGINT dalam satuan 1/100 (lihat tipe Money).
+-- COMMENT kolom dipakai sebagai penanda supaya database yang sudah
+-- dikonversi sebelumnya tidak dikalikan 100 untuk kedua kalinya.
+
+DO $$
+BEGIN
+    IF col_description('user_transactions'::regclass,
+        (SELECT attnum FROM pg_attribute WHERE attrelid = 'user_transactions'::regclass AND attname = 'pemasukan'))
+        IS DISTINCT FROM 'money:minor_units' THEN
+        ALTER TABLE user_transactions ALTER COLUMN pemasukan TYPE BIGINT USING ROUND(pemasukan::numeric * 100)::bigint;
+        COMMENT ON COLUMN user_transactions.pemasukan IS 'money:minor_units';
+    END IF;
+END $$;
+
+DO $$
+BEGIN
+    IF col_description('user_transactions'::regclass,
+        (SELECT attnum FROM pg_attribute WHERE attrelid = 'user_transactions'::regclass AND attname = 'pengeluaran'))
+        IS DISTINCT FROM 'money:minor_units' THEN
+        ALTER TABLE user_transactions ALTER COLUMN pengeluaran TYPE BIGINT USING ROUND(pengeluaran::numeric * 100)::bigint;
+        COMMENT ON COLUMN user_transactions.pengeluaran IS 'money:minor_units';
+    END IF;
+END $$;
+
+DO $$
+BEGIN
+    IF col_description('other_transaction'::regclass,
+        (SELECT attnum FROM pg_attribute WHERE attrelid = 'other_transaction'::regclass AND attname = 'nominal'))
+        IS DISTINCT FROM 'money:minor_units' THEN
+        ALTER TABLE other_transaction ALTER COLUMN nominal TYPE BIGINT USING ROUND(nominal::numeric * 100)::bigint;
+        COMMENT ON COLUMN other_transaction.nominal IS 'money:minor_units';
+    END IF;
+END $$;
+
+DO $$
+BEGIN
+    IF col_description('room_balance'::regclass,
+        (SELECT attnum FROM pg_attribute WHERE attrelid = 'room_balance'::regclass AND attname = 'total_saldo'))
+        IS DISTINCT FROM 'money:minor_units' THEN
+        ALTER TABLE room_balance ALTER COLUMN total_saldo TYPE BIGINT USING ROUND(total_saldo::numeric * 100)::bigint;
+        COMMENT ON COLUMN room_balance.total_saldo IS 'money:minor_units';
+    END IF;
+END $$;
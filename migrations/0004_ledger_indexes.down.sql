@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_other_transaction_tanggal_update;
+DROP INDEX IF EXISTS idx_other_transaction_user_id;
+DROP INDEX IF EXISTS idx_other_transaction_room_id;
+
+DROP INDEX IF EXISTS idx_user_transactions_tanggal_update;
+DROP INDEX IF EXISTS idx_user_transactions_user_id;
+DROP INDEX IF EXISTS idx_user_transactions_room_id;
+
+DROP INDEX IF EXISTS idx_users_room_id;
@@ -0,0 +1,9 @@
+CREATE INDEX IF NOT EXISTS idx_users_room_id ON users (room_id);
+
+CREATE INDEX IF NOT EXISTS idx_user_transactions_room_id ON user_transactions (room_id);
+CREATE INDEX IF NOT EXISTS idx_user_transactions_user_id ON user_transactions (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_transactions_tanggal_update ON user_transactions (tanggal_update);
+
+CREATE INDEX IF NOT EXISTS idx_other_transaction_room_id ON other_transaction (room_id);
+CREATE INDEX IF NOT EXISTS idx_other_transaction_user_id ON other_transaction (user_id);
+CREATE INDEX IF NOT EXISTS idx_other_transaction_tanggal_update ON other_transaction (tanggal_update);
@@ -0,0 +1,51 @@
+CREATE TABLE user_transactions (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id         INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    pemasukan       BIGINT NOT NULL DEFAULT 0,
+    pengeluaran     BIGINT NOT NULL DEFAULT 0,
+    tanggal_update  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE other_transaction (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id         INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    jenis           TEXT NOT NULL CHECK (jenis IN ('Pemasukan', 'Pengeluaran')),
+    kategori        TEXT NOT NULL,
+    nominal         BIGINT NOT NULL,
+    keterangan      TEXT NOT NULL DEFAULT '',
+    tanggal_update  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON COLUMN user_transactions.pemasukan IS 'money:minor_units';
+COMMENT ON COLUMN user_transactions.pengeluaran IS 'money:minor_units';
+COMMENT ON COLUMN other_transaction.nominal IS 'money:minor_units';
+
+INSERT INTO user_transactions (id, user_id, room_id, pemasukan, pengeluaran, tanggal_update)
+SELECT legacy_user_id, user_id, room_id,
+       CASE WHEN jenis = 'Pemasukan' THEN nominal ELSE 0 END,
+       CASE WHEN jenis = 'Pengeluaran' THEN nominal ELSE 0 END,
+       tanggal_update
+FROM transactions
+WHERE legacy_user_id IS NOT NULL;
+
+INSERT INTO other_transaction (id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update)
+SELECT id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update
+FROM transactions
+WHERE legacy_user_id IS NULL;
+
+SELECT setval(pg_get_serial_sequence('user_transactions', 'id'),
+              COALESCE((SELECT MAX(id) FROM user_transactions), 0) + 1, false);
+SELECT setval(pg_get_serial_sequence('other_transaction', 'id'),
+              COALESCE((SELECT MAX(id) FROM other_transaction), 0) + 1, false);
+
+CREATE INDEX idx_user_transactions_room_id ON user_transactions (room_id);
+CREATE INDEX idx_user_transactions_user_id ON user_transactions (user_id);
+CREATE INDEX idx_user_transactions_tanggal_update ON user_transactions (tanggal_update);
+
+CREATE INDEX idx_other_transaction_room_id ON other_transaction (room_id);
+CREATE INDEX idx_other_transaction_user_id ON other_transaction (user_id);
+CREATE INDEX idx_other_transaction_tanggal_update ON other_transaction (tanggal_update);
+
+DROP TABLE transactions;
@@ -0,0 +1,54 @@
+-- Satu tabel transaksi untuk seluruh room, menggantikan user_transactions
+-- dan other_transaction.
+--
+-- ID dipertahankan untuk client lama:
+--   * baris other_transaction memakai id yang sama persis;
+--   * baris user_transactions mendapat id baru, id lamanya disimpan di
+--     legacy_user_id dan tetap dipakai endpoint lama (/edit-transaksi-user,
+--     /hapus-transaksi-user). Transaksi baru dari /pemasukan dan
+--     /pengeluaran melanjutkan nomor dari sequence legacy.
+--
+-- kategori kosong = transaksi pribadi tanpa kategori (asal user_transactions).
+
+CREATE TABLE transactions (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id         INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    jenis           TEXT NOT NULL CHECK (jenis IN ('Pemasukan', 'Pengeluaran')),
+    kategori        TEXT NOT NULL DEFAULT '',
+    nominal         BIGINT NOT NULL CHECK (nominal >= 0),
+    keterangan      TEXT NOT NULL DEFAULT '',
+    tanggal_update  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    legacy_user_id  INT UNIQUE
+);
+
+COMMENT ON COLUMN transactions.nominal IS 'money:minor_units';
+
+CREATE SEQUENCE transactions_legacy_user_id_seq OWNED BY transactions.legacy_user_id;
+
+INSERT INTO transactions (id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update)
+SELECT id, user_id, room_id, jenis, kategori, nominal, keterangan, tanggal_update
+FROM other_transaction;
+
+SELECT setval(pg_get_serial_sequence('transactions', 'id'),
+              COALESCE((SELECT MAX(id) FROM transactions), 0) + 1, false);
+
+-- Baris lama hanya mengisi salah satu kolom; selisihnya dipakai supaya
+-- saldo room tetap sama persis jika ada baris yang mengisi keduanya.
+INSERT INTO transactions (user_id, room_id, jenis, kategori, nominal, tanggal_update, legacy_user_id)
+SELECT user_id, room_id,
+       CASE WHEN COALESCE(pemasukan, 0) >= COALESCE(pengeluaran, 0) THEN 'Pemasukan' ELSE 'Pengeluaran' END,
+       '',
+       ABS(COALESCE(pemasukan, 0) - COALESCE(pengeluaran, 0)),
+       tanggal_update, id
+FROM user_transactions
+ORDER BY id;
+
+SELECT setval('transactions_legacy_user_id_seq',
+              COALESCE((SELECT MAX(id) FROM user_transactions), 0) + 1, false);
+
+CREATE INDEX idx_transactions_room_id_tanggal ON transactions (room_id, tanggal_update DESC);
+CREATE INDEX idx_transactions_user_id ON transactions (user_id);
+
+DROP TABLE user_transactions;
+DROP TABLE other_transaction;
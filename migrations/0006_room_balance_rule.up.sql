@@ -0,0 +1,3 @@
+-- Aturan per room: jika true, perubahan yang membuat room_balance.total_saldo
+-- menjadi negatif (mis. menghapus pemasukan) ditolak.
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS forbid_negative_balance BOOLEAN NOT NULL DEFAULT FALSE;
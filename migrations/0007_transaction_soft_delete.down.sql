@@ -0,0 +1,9 @@
+-- Transaksi yang masih di tempat sampah sudah tidak dihitung di
+-- room_balance, jadi ikut dihapus permanen.
+DELETE FROM transactions WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_transactions_trash;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
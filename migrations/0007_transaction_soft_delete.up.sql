@@ -0,0 +1,8 @@
+-- Soft delete: transaksi yang dihapus tetap disimpan (tempat sampah) sampai
+-- dihapus permanen oleh job purge setelah masa retensi.
+ALTER TABLE transactions
+    ADD COLUMN deleted_at TIMESTAMPTZ,
+    ADD COLUMN deleted_by INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_trash ON transactions (room_id, deleted_at DESC)
+    WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transaction_audit;
+DROP FUNCTION IF EXISTS transaction_audit_append_only();
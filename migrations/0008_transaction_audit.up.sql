@@ -0,0 +1,30 @@
+-- Log audit transaksi: satu baris per perubahan (create, update, delete,
+-- restore). Tabel ini append-only; UPDATE dan DELETE ditolak trigger.
+-- Sengaja tanpa foreign key supaya riwayat tetap ada walaupun transaksinya
+-- sudah dihapus permanen dari tempat sampah.
+CREATE TABLE transaction_audit (
+    id              BIGSERIAL PRIMARY KEY,
+    transaction_id  INT NOT NULL,
+    room_id         INT NOT NULL,
+    user_id         INT NOT NULL,
+    action          TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    before          JSONB,
+    after           JSONB,
+    saldo_delta     BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON COLUMN transaction_audit.saldo_delta IS 'money:minor_units';
+
+CREATE INDEX idx_transaction_audit_transaction ON transaction_audit (transaction_id, id);
+CREATE INDEX idx_transaction_audit_room ON transaction_audit (room_id, id DESC);
+
+CREATE FUNCTION transaction_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'transaction_audit bersifat append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transaction_audit_no_change
+    BEFORE UPDATE OR DELETE ON transaction_audit
+    FOR EACH ROW EXECUTE FUNCTION transaction_audit_append_only();
@@ -0,0 +1,4 @@
+-- Versi baris untuk optimistic concurrency: naik setiap kali transaksi
+-- diubah, dihapus atau dipulihkan. Client mengirimnya balik (If-Match atau
+-- field version) saat mengedit.
+ALTER TABLE transactions ADD COLUMN version INT NOT NULL DEFAULT 1;
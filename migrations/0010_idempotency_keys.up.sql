@@ -0,0 +1,16 @@
+-- Idempotency-Key untuk endpoint POST transaksi: respon pertama disimpan
+-- dan diputar ulang saat client mengirim ulang request dengan key yang
+-- sama. status_code 0 berarti request pertama masih diproses.
+CREATE TABLE idempotency_keys (
+    user_id      INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key          TEXT NOT NULL,
+    fingerprint  TEXT NOT NULL,
+    status_code  INT NOT NULL DEFAULT 0,
+    headers      JSONB,
+    body         BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys (expires_at);
@@ -0,0 +1,7 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS timezone;
+
+DROP INDEX IF EXISTS idx_transactions_room_id_occurred;
+ALTER TABLE transactions DROP COLUMN IF EXISTS created_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS occurred_at;
+ALTER TABLE transactions RENAME COLUMN updated_at TO tanggal_update;
+CREATE INDEX idx_transactions_room_id_tanggal ON transactions (room_id, tanggal_update DESC);
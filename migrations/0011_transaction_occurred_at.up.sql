@@ -0,0 +1,19 @@
+-- Tanggal kejadian transaksi dipisah dari waktu pencatatan:
+--   occurred_at → diisi client (boleh mundur/maju), dasar listing & rekap harian
+--   created_at  → kapan baris dibuat
+--   updated_at  → kapan baris terakhir diubah (dulu tanggal_update)
+-- Data lama hanya punya tanggal_update, jadi ketiganya diisi dari situ.
+ALTER TABLE transactions RENAME COLUMN tanggal_update TO updated_at;
+ALTER TABLE transactions ADD COLUMN occurred_at TIMESTAMPTZ;
+ALTER TABLE transactions ADD COLUMN created_at TIMESTAMPTZ;
+UPDATE transactions SET occurred_at = updated_at, created_at = updated_at;
+ALTER TABLE transactions ALTER COLUMN occurred_at SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN occurred_at SET DEFAULT NOW();
+ALTER TABLE transactions ALTER COLUMN created_at SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN created_at SET DEFAULT NOW();
+
+DROP INDEX IF EXISTS idx_transactions_room_id_tanggal;
+CREATE INDEX idx_transactions_room_id_occurred ON transactions (room_id, occurred_at DESC);
+
+-- Zona waktu room untuk occurred_at; kosong = APP_TIMEZONE.
+ALTER TABLE rooms ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_category_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,40 @@
+-- Kategori transaksi per room, menggantikan daftar tetap di kode. Nama unik
+-- per room (tidak case-sensitive) termasuk sub-kategori, supaya field
+-- kategori di request tetap bisa dicocokkan lewat nama. Kategori tidak
+-- pernah dihapus, hanya diarsipkan.
+CREATE TABLE categories (
+    id           SERIAL PRIMARY KEY,
+    room_id      INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    parent_id    INT REFERENCES categories(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    icon         TEXT NOT NULL DEFAULT '',
+    color        TEXT NOT NULL DEFAULT '',
+    archived_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_categories_room_name ON categories (room_id, LOWER(name));
+
+-- Kategori bawaan untuk room yang sudah ada (sama dengan defaultCategories
+-- di categories.go).
+INSERT INTO categories (room_id, name, icon, color)
+SELECT r.id, d.name, d.icon, d.color
+FROM rooms r
+CROSS JOIN (VALUES
+    (1, 'Makanan', '🍔', '#F97316'),
+    (2, 'Belanja', '🛒', '#3B82F6'),
+    (3, 'Hiburan', '🎬', '#A855F7'),
+    (4, 'Tagihan', '🧾', '#EF4444'),
+    (5, 'Lainnya', '📦', '#6B7280')
+) AS d(pos, name, icon, color)
+ORDER BY r.id, d.pos;
+
+-- Transaksi menunjuk kategori lewat id; kolom kategori tetap menyimpan
+-- nama (ikut diperbarui saat kategori di-rename).
+ALTER TABLE transactions ADD COLUMN category_id INT REFERENCES categories(id) ON DELETE SET NULL;
+
+UPDATE transactions t SET category_id = c.id
+FROM categories c
+WHERE c.room_id = t.room_id AND LOWER(c.name) = LOWER(t.kategori) AND t.kategori <> '';
+
+CREATE INDEX idx_transactions_category_id ON transactions (category_id);
@@ -0,0 +1,18 @@
+-- Tag bebas per room; satu transaksi boleh punya banyak tag. Nama tag unik
+-- per room tanpa membedakan huruf besar/kecil, ejaan pertama yang dipakai.
+CREATE TABLE tags (
+    id          SERIAL PRIMARY KEY,
+    room_id     INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_tags_room_name ON tags (room_id, LOWER(name));
+
+CREATE TABLE transaction_tags (
+    transaction_id  INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tag_id          INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE INDEX idx_transaction_tags_tag_id ON transaction_tags (tag_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_room_id_nominal;
+DROP INDEX IF EXISTS idx_transactions_room_id_occurred;
+CREATE INDEX idx_transactions_room_id_occurred ON transactions (room_id, occurred_at DESC);
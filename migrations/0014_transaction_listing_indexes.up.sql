@@ -0,0 +1,5 @@
+-- Keyset pagination memakai (kolom urutan, id); sertakan id di index supaya
+-- halaman berikutnya tidak perlu sort ulang.
+DROP INDEX IF EXISTS idx_transactions_room_id_occurred;
+CREATE INDEX idx_transactions_room_id_occurred ON transactions (room_id, occurred_at DESC, id DESC);
+CREATE INDEX idx_transactions_room_id_nominal ON transactions (room_id, nominal DESC, id DESC);
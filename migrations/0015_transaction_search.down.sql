@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS transaction_tags_search ON transaction_tags;
+DROP FUNCTION IF EXISTS transaction_tags_search_trigger();
+DROP TRIGGER IF EXISTS transactions_search ON transactions;
+DROP FUNCTION IF EXISTS transactions_search_trigger();
+DROP INDEX IF EXISTS idx_transactions_search;
+ALTER TABLE transactions DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS transaction_search_vector(INT, TEXT, TEXT);
+DROP TEXT SEARCH CONFIGURATION IF EXISTS wallet_search;
@@ -0,0 +1,57 @@
+-- Pencarian teks transaksi (lihat search.go). Konfigurasi wallet_search
+-- memakai stemmer bahasa Indonesia jika tersedia (PostgreSQL 12+), selain
+-- itu 'simple'.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'indonesian') THEN
+        CREATE TEXT SEARCH CONFIGURATION wallet_search (COPY = pg_catalog.indonesian);
+    ELSE
+        CREATE TEXT SEARCH CONFIGURATION wallet_search (COPY = pg_catalog.simple);
+    END IF;
+END $$;
+
+-- Dokumen pencarian: kategori dan tag berbobot A, keterangan berbobot B.
+CREATE FUNCTION transaction_search_vector(p_id INT, p_kategori TEXT, p_keterangan TEXT)
+RETURNS tsvector LANGUAGE sql STABLE AS $$
+    SELECT setweight(to_tsvector('wallet_search', COALESCE(p_kategori, '')), 'A')
+        || setweight(to_tsvector('wallet_search', COALESCE(
+               (SELECT string_agg(tg.name, ' ')
+                FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id
+                WHERE tt.transaction_id = p_id), '')), 'A')
+        || setweight(to_tsvector('wallet_search', COALESCE(p_keterangan, '')), 'B')
+$$;
+
+ALTER TABLE transactions ADD COLUMN search_vector tsvector NOT NULL DEFAULT ''::tsvector;
+UPDATE transactions SET search_vector = transaction_search_vector(id, kategori, keterangan);
+CREATE INDEX idx_transactions_search ON transactions USING GIN (search_vector);
+
+-- search_vector diperbarui saat kategori/keterangan berubah (termasuk
+-- rename kategori) dan saat tag transaksi diganti.
+CREATE FUNCTION transactions_search_trigger() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector := transaction_search_vector(NEW.id, NEW.kategori, NEW.keterangan);
+    RETURN NEW;
+END $$;
+
+CREATE TRIGGER transactions_search
+    BEFORE INSERT OR UPDATE OF kategori, keterangan ON transactions
+    FOR EACH ROW EXECUTE FUNCTION transactions_search_trigger();
+
+CREATE FUNCTION transaction_tags_search_trigger() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    tx_id INT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        tx_id := OLD.transaction_id;
+    ELSE
+        tx_id := NEW.transaction_id;
+    END IF;
+    UPDATE transactions
+    SET search_vector = transaction_search_vector(id, kategori, keterangan)
+    WHERE id = tx_id;
+    RETURN NULL;
+END $$;
+
+CREATE TRIGGER transaction_tags_search
+    AFTER INSERT OR DELETE ON transaction_tags
+    FOR EACH ROW EXECUTE FUNCTION transaction_tags_search_trigger();
@@ -0,0 +1,8 @@
+-- ID transaksi dari bank (FITID di OFX, AcctSvcrRef di CAMT.053) untuk
+-- transaksi hasil import mutasi rekening. Unik per room supaya mutasi
+-- yang sama tidak bisa diimport dua kali, termasuk yang sudah di tempat
+-- sampah.
+ALTER TABLE transactions ADD COLUMN external_id TEXT;
+
+CREATE UNIQUE INDEX idx_transactions_room_external_id
+    ON transactions (room_id, external_id) WHERE external_id IS NOT NULL;
@@ -1,17 +1,148 @@
 package main
 
+import "time"
+
 type RegisterRequest struct {
 	FullName        string `json:"full_name"`
 	Email           string `json:"email"`
 	Password        string `json:"password"`
 	ConfirmPassword string `json:"confirm_password"`
-	RoomID          int    `json:"room_id"`
 }
 
 type User struct {
-	ID        int    `json:"id"`
-	FullName  string `json:"full_name"`
-	Email     string `json:"email"`
-	RoomID    int    `json:"room_id"`
-	CreatedAt string `json:"created_at"`
+	ID           int       `json:"id"`
+	FullName     string    `json:"full_name"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	RoomID       int       `json:"room_id"` // 0 = belum punya room
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Room struct {
+	ID                    int       `json:"id"`
+	RoomName              string    `json:"room_name"`
+	OwnerID               int       `json:"owner_id"`
+	Currency              string    `json:"currency"`
+	ForbidNegativeBalance bool      `json:"forbid_negative_balance"` // tolak perubahan yang membuat saldo negatif
+	Timezone              string    `json:"timezone"`                // kosong = APP_TIMEZONE, lihat occurred.go
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type RoomInvite struct {
+	Code      string
+	RoomID    int
+	CreatedBy int
+	ExpiresAt time.Time
+	UsedBy    int
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// Category adalah kategori transaksi milik sebuah room (tabel categories).
+// ParentID 0 berarti kategori utama; sub-kategori hanya satu tingkat.
+type Category struct {
+	ID         int        `json:"id"`
+	RoomID     int        `json:"room_id"`
+	ParentID   int        `json:"parent_id,omitempty"`
+	Name       string     `json:"name"`
+	Icon       string     `json:"icon"`
+	Color      string     `json:"color"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TagUsage adalah tag room beserta jumlah transaksi (belum dihapus) yang
+// memakainya, untuk autocomplete.
+type TagUsage struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TagTotals adalah rekap pemasukan/pengeluaran per tag.
+type TagTotals struct {
+	Tag         string `json:"tag"`
+	Pemasukan   Money  `json:"total_pemasukan"`
+	Pengeluaran Money  `json:"total_pengeluaran"`
+	Count       int    `json:"jumlah_transaksi"`
+}
+
+// IdempotencyRecord adalah respon yang disimpan untuk satu Idempotency-Key
+// (tabel idempotency_keys). StatusCode 0 berarti request pertama masih
+// diproses.
+type IdempotencyRecord struct {
+	UserID      int
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Transaction adalah satu baris ledger room (tabel transactions).
+// Kategori kosong berarti transaksi pribadi tanpa kategori yang dibuat
+// lewat endpoint lama /pemasukan atau /pengeluaran; baris seperti itu
+// juga punya LegacyUserID (id versi user_transactions) untuk client lama.
+type Transaction struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	RoomID       int       `json:"room_id"`
+	Jenis        string    `json:"jenis"`
+	Kategori     string    `json:"kategori"`              // nama kategori, ikut berubah saat di-rename
+	CategoryID   int       `json:"category_id,omitempty"` // 0 = tanpa kategori
+	Tags         []string  `json:"tags"`                  // urut nama, lihat tags.go
+	Nominal      Money     `json:"nominal"`
+	Keterangan   string    `json:"keterangan"`
+	OccurredAt   time.Time `json:"occurred_at"` // tanggal kejadian dari client
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	LegacyUserID *int      `json:"-"`
+	Version      int       `json:"version"`               // naik setiap perubahan, lihat etag.go
+	ExternalID   string    `json:"external_id,omitempty"` // id transaksi dari bank, lihat import_bank.go
+
+	// Terisi jika transaksi sedang di tempat sampah (soft delete).
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy int        `json:"deleted_by,omitempty"`
+}
+
+// SearchHit adalah satu hasil pencarian transaksi (lihat search.go).
+type SearchHit struct {
+	Transaction
+	Rank      float32         `json:"rank"`
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+// SearchHighlight berisi field yang cocok dengan kata yang dicari; kata
+// yang cocok dibungkus <mark>…</mark> dan sisa teksnya di-escape HTML.
+type SearchHighlight struct {
+	Keterangan string   `json:"keterangan,omitempty"`
+	Kategori   string   `json:"kategori,omitempty"`
+	Tags       []string `json:"tags,omitempty"` // tag yang cocok, tanpa markup
+}
+
+// Delta adalah pengaruh transaksi terhadap saldo room.
+func (t Transaction) Delta() Money { return jenisSign(t.Jenis) * t.Nominal }
+
+// AuditEntry adalah satu baris log audit transaksi (tabel transaction_audit).
+// Before kosong untuk aksi create; aksi delete menyimpan After lengkap
+// dengan deleted_at-nya.
+type AuditEntry struct {
+	ID            int64        `json:"id"`
+	TransactionID int          `json:"transaction_id"`
+	RoomID        int          `json:"room_id"`
+	UserID        int          `json:"user_id"` // pelaku perubahan
+	Action        string       `json:"action"`  // create, update, delete, restore
+	Before        *Transaction `json:"before"`
+	After         *Transaction `json:"after"`
+	SaldoDelta    Money        `json:"saldo_delta"`
+	CreatedAt     time.Time    `json:"created_at"`
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ==========================
+// 🔹 Tipe uang (Money)
+// ==========================
+//
+// Money menyimpan nominal sebagai bilangan bulat dalam satuan 1/100 unit
+// mata uang, sehingga penjumlahan dan pengurangan selalu eksak (tidak ada
+// pembulatan float). Di database disimpan sebagai BIGINT dengan satuan
+// yang sama, di JSON ditulis sebagai angka desimal biasa:
+//
+//	Money(1500000) ⇄ 15000      Money(1250) ⇄ 12.5
+type Money int64
+
+const (
+	moneyScale    = 100
+	moneyMaxScale = 2
+)
+
+// currencyExponent adalah jumlah angka di belakang koma yang diizinkan per
+// mata uang. Rupiah tidak memakai sen dalam praktik, jadi nominal IDR
+// harus bilangan bulat.
+var currencyExponent = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"USD": 2,
+	"EUR": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"AUD": 2,
+}
+
+const defaultCurrency = "IDR"
+
+var errMoneyFormat = errors.New("format nominal tidak valid")
+
+// parseMoney membaca angka desimal secara eksak (tanpa lewat float64).
+func parseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errMoneyFormat
+	}
+
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" || (hasDot && fracPart == "") {
+		return 0, errMoneyFormat
+	}
+	if len(fracPart) > moneyMaxScale {
+		return 0, fmt.Errorf("nominal maksimal %d angka di belakang koma", moneyMaxScale)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, errMoneyFormat
+			}
+		}
+	}
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || units > math.MaxInt64/moneyScale-1 {
+		return 0, errors.New("nominal terlalu besar")
+	}
+
+	frac := 0
+	if fracPart != "" {
+		frac, _ = strconv.Atoi(fracPart + strings.Repeat("0", moneyMaxScale-len(fracPart)))
+	}
+
+	m := Money(units*moneyScale + int64(frac))
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// String menulis nominal tanpa nol berlebih di belakang koma.
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	units, frac := v/moneyScale, v%moneyScale
+	if frac == 0 {
+		return fmt.Sprintf("%s%d", sign, units)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%02d", sign, units, frac), "0")
+}
+
+// Fixed menulis nominal dengan tepat dua angka di belakang koma.
+func (m Money) Fixed() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/moneyScale, v%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON menerima angka (15000.5) maupun string ("15000.5").
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) > 1 && b[0] == '"' {
+		unq, err := strconv.Unquote(string(b))
+		if err != nil {
+			return errMoneyFormat
+		}
+		b = []byte(unq)
+	}
+
+	v, err := parseMoney(string(b))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// fitsCurrency mengecek apakah nominal tidak lebih presisi daripada yang
+// diizinkan mata uang tsb (mis. IDR tidak boleh punya sen).
+func (m Money) fitsCurrency(currency string) bool {
+	exp, ok := currencyExponent[currency]
+	if !ok {
+		exp = moneyMaxScale
+	}
+	step := int64(1)
+	for i := exp; i < moneyMaxScale; i++ {
+		step *= 10
+	}
+	return int64(m)%step == 0
+}
+
+// validateAmount dipakai handler untuk memastikan nominal positif dan
+// sesuai presisi mata uang room. Mengembalikan pesan error kosong jika valid.
+func validateAmount(m Money, currency string) string {
+	if m <= 0 {
+		return "Nominal harus lebih dari 0"
+	}
+	if !m.fitsCurrency(currency) {
+		return fmt.Sprintf("Nominal %s terlalu presisi untuk mata uang %s", m, currency)
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// 🔹 Tanggal transaksi (occurred_at) & zona waktu room
+// ==========================
+//
+// occurred_at adalah tanggal kejadian yang diisi client (boleh mundur atau
+// maju), terpisah dari created_at/updated_at yang diisi server. Semua
+// listing dan pengelompokan harian memakai occurred_at di zona waktu room;
+// room tanpa zona waktu memakai APP_TIMEZONE.
+
+var locationCache sync.Map // nama zona → *time.Location
+
+// loadTimezone memvalidasi dan meng-cache nama zona waktu IANA.
+func loadTimezone(name string) (*time.Location, error) {
+	if l, ok := locationCache.Load(name); ok {
+		return l.(*time.Location), nil
+	}
+	l, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, l)
+	return l, nil
+}
+
+// roomLocation mengembalikan zona waktu room.
+func roomLocation(room Room) *time.Location {
+	if room.Timezone == "" {
+		return loc
+	}
+	l, err := loadTimezone(room.Timezone)
+	if err != nil {
+		return loc
+	}
+	return l
+}
+
+// Format occurred_at yang diterima. Tanpa offset berarti jam di zona waktu
+// room; tanpa jam berarti pukul 00:00.
+var occurredAtLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseOccurredAt membaca occurred_at dari client.
+func parseOccurredAt(s string, tz *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range occurredAtLayouts {
+		if t, err := time.ParseInLocation(layout, s, tz); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("occurred_at harus berformat YYYY-MM-DD, YYYY-MM-DD HH:MM atau RFC3339")
+}
+
+// roomOccurredAt membaca occurred_at dari body request memakai zona waktu
+// room. Format salah menjadi error 400 (lihat writeTxError).
+func (s *Server) roomOccurredAt(ctx context.Context, roomID int, raw string) (time.Time, error) {
+	room, err := s.store.Rooms().Get(ctx, roomID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Gagal membaca room: %w", err)
+	}
+	t, err := parseOccurredAt(raw, roomLocation(room))
+	if err != nil {
+		return time.Time{}, clientError(http.StatusBadRequest, "invalid_occurred_at", err.Error())
+	}
+	return t, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ==========================
+// 🔹 Hash password (argon2id)
+// ==========================
+//
+// Format hash yang disimpan mengikuti PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt base64>$<hash base64>
+//
+// sehingga algoritma dan parameternya ikut tersimpan. Hash lama berupa
+// hex SHA-256 tanpa salt masih bisa diverifikasi, lalu di-upgrade saat
+// login berhasil.
+
+type argon2Params struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+var currentArgon2Params = argon2Params{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 4,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+var errInvalidPasswordHash = errors.New("format hash password tidak dikenali")
+
+func hashPassword(pw string) (string, error) {
+	p := currentArgon2Params
+
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("gagal membuat salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(pw), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// verifyPassword mengecek password terhadap hash tersimpan. needsRehash
+// bernilai true jika hash valid tetapi memakai algoritma lama atau
+// parameter yang berbeda dari currentArgon2Params.
+func verifyPassword(pw, stored string) (ok bool, needsRehash bool, err error) {
+	if strings.HasPrefix(stored, "$argon2id$") {
+		p, salt, key, err := decodeArgon2Hash(stored)
+		if err != nil {
+			return false, false, err
+		}
+
+		other := argon2.IDKey([]byte(pw), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return false, false, nil
+		}
+
+		needsRehash = p.Memory != currentArgon2Params.Memory ||
+			p.Time != currentArgon2Params.Time ||
+			p.Threads != currentArgon2Params.Threads ||
+			uint32(len(salt)) != currentArgon2Params.SaltLen ||
+			uint32(len(key)) != currentArgon2Params.KeyLen
+		return true, needsRehash, nil
+	}
+
+	// 🔹 Hash lama: hex SHA-256 tanpa salt
+	if len(stored) == sha256.Size*2 {
+		want, err := hex.DecodeString(stored)
+		if err != nil {
+			return false, false, errInvalidPasswordHash
+		}
+		got := sha256.Sum256([]byte(pw))
+		if subtle.ConstantTimeCompare(want, got[:]) != 1 {
+			return false, false, nil
+		}
+		return true, true, nil
+	}
+
+	return false, false, errInvalidPasswordHash
+}
+
+func decodeArgon2Hash(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, errInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errInvalidPasswordHash
+	}
+
+	p.SaltLen = uint32(len(salt))
+	p.KeyLen = uint32(len(key))
+	return p, salt, key, nil
+}
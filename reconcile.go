@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ==========================
+// 🔹 Rekonsiliasi room_balance
+// ==========================
+//
+// Saldo yang benar untuk sebuah room adalah:
+//
+//	SUM(transactions.nominal Pemasukan) - SUM(transactions.nominal Pengeluaran)
+//
+// Rekonsiliasi menghitung ulang nilai itu, membandingkannya dengan
+// room_balance.total_saldo, dan (jika diminta) memperbaikinya. Mode default
+// adalah dry-run: tidak ada data yang diubah.
+
+type ledgerContribution struct {
+	Source     string    `json:"source"`
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Jenis      string    `json:"jenis"`
+	Delta      Money     `json:"delta"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type roomReconciliation struct {
+	RoomID         int                  `json:"room_id"`
+	StoredSaldo    Money                `json:"stored_saldo"`
+	ExpectedSaldo  Money                `json:"expected_saldo"`
+	Selisih        Money                `json:"selisih"`
+	BalanceMissing bool                 `json:"balance_missing"`
+	Repaired       bool                 `json:"repaired"`
+	Contributions  []ledgerContribution `json:"contributions,omitempty"`
+}
+
+func (rr roomReconciliation) drifted() bool {
+	return rr.BalanceMissing || rr.Selisih != 0
+}
+
+type reconcileOptions struct {
+	RoomID      int  // 0 = semua room
+	Repair      bool // false = dry-run
+	WithRows    bool // sertakan daftar baris ledger untuk room yang selisih
+	IncludeSame bool // sertakan juga room yang sudah cocok
+}
+
+func reconcileRooms(ctx context.Context, st Store, opts reconcileOptions) ([]roomReconciliation, error) {
+	roomIDs := []int{opts.RoomID}
+	if opts.RoomID == 0 {
+		var err error
+		roomIDs, err = st.Rooms().ListIDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("gagal mengambil daftar room: %w", err)
+		}
+	} else if _, err := st.Rooms().Get(ctx, opts.RoomID); errors.Is(err, ErrNotFound) {
+		roomIDs = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar room: %w", err)
+	}
+
+	results := []roomReconciliation{}
+	for _, roomID := range roomIDs {
+		rr, err := reconcileRoom(ctx, st, roomID, opts)
+		if err != nil {
+			return results, fmt.Errorf("room %d: %w", roomID, err)
+		}
+		if rr.drifted() || opts.IncludeSame {
+			results = append(results, rr)
+		}
+	}
+	return results, nil
+}
+
+// errDryRun membatalkan transaksi rekonsiliasi yang tidak memperbaiki apa pun.
+var errDryRun = errors.New("dry-run")
+
+// reconcileRoom memeriksa satu room di dalam DB transaction. Baris
+// room_balance dikunci supaya tidak ada mutasi lain di tengah perhitungan.
+func reconcileRoom(ctx context.Context, st Store, roomID int, opts reconcileOptions) (roomReconciliation, error) {
+	rr := roomReconciliation{RoomID: roomID}
+
+	err := st.WithTx(ctx, func(tx Store) error {
+		var err error
+		rr.StoredSaldo, err = tx.Balances().Lock(ctx, roomID)
+		if errors.Is(err, ErrNotFound) {
+			rr.BalanceMissing = true
+		} else if err != nil {
+			return fmt.Errorf("gagal membaca saldo: %w", err)
+		}
+
+		filter := LedgerFilter{RoomID: roomID}
+		totals, err := tx.Transactions().Sum(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("gagal menghitung ledger: %w", err)
+		}
+		rr.ExpectedSaldo = totals.Saldo()
+
+		rr.Selisih = rr.StoredSaldo - rr.ExpectedSaldo
+		if !rr.drifted() {
+			return errDryRun
+		}
+
+		if opts.WithRows {
+			rr.Contributions, err = ledgerContributions(ctx, tx, filter)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !opts.Repair {
+			return errDryRun
+		}
+
+		if rr.BalanceMissing {
+			err = tx.Balances().Create(ctx, roomID, rr.ExpectedSaldo)
+		} else {
+			err = setRoomBalance(ctx, tx, roomID, rr.ExpectedSaldo)
+		}
+		return err
+	})
+	if errors.Is(err, errDryRun) {
+		return rr, nil
+	}
+	if err != nil {
+		return rr, err
+	}
+	rr.Repaired = true
+	return rr, nil
+}
+
+func ledgerContributions(ctx context.Context, tx Store, filter LedgerFilter) ([]ledgerContribution, error) {
+	filter.Ascending = true
+
+	rows, err := tx.Transactions().List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil baris ledger: %w", err)
+	}
+
+	list := make([]ledgerContribution, 0, len(rows))
+	for _, t := range rows {
+		list = append(list, ledgerContribution{
+			Source: "transactions", ID: t.ID, UserID: t.UserID,
+			Jenis: t.Jenis, Delta: t.Delta(), OccurredAt: t.OccurredAt,
+		})
+	}
+	return list, nil
+}
+
+// ==========================
+// 🔹 Middleware: khusus admin
+// ==========================
+//
+// Endpoint admin memakai header X-Admin-Token yang dicocokkan dengan
+// ADMIN_TOKEN di config. Jika ADMIN_TOKEN kosong, endpoint admin dimatikan.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminToken := cfg.AdminToken
+		given := r.Header.Get("X-Admin-Token")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(given), []byte(adminToken)) != 1 {
+			writeError(w, http.StatusForbidden, "admin_only", "Endpoint ini khusus admin")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ==========================
+// 🔹 API Admin: Rekonsiliasi saldo
+// ==========================
+//
+// GET  /admin/reconcile?room_id=&all=true       → dry-run
+// POST /admin/reconcile?room_id=&repair=true    → perbaiki saldo
+func (s *Server) ReconcileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Hanya GET atau POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	opts := reconcileOptions{
+		WithRows:    q.Get("rows") != "false",
+		IncludeSame: q.Get("all") == "true",
+	}
+	if s := q.Get("room_id"); s != "" {
+		roomID, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "room_id tidak valid", http.StatusBadRequest)
+			return
+		}
+		opts.RoomID = roomID
+	}
+	if q.Get("repair") == "true" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Perbaikan saldo wajib memakai POST", http.StatusMethodNotAllowed)
+			return
+		}
+		opts.Repair = true
+	}
+
+	results, err := reconcileRooms(r.Context(), s.store, opts)
+	if err != nil {
+		http.Error(w, "Gagal rekonsiliasi: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "success",
+		"dry_run":    !opts.Repair,
+		"total_room": len(results),
+		"rooms":      results,
+	})
+}
+
+// ==========================
+// 🔹 CLI: backend reconcile [-room N] [-apply] [-rows] [-json]
+// ==========================
+func runReconcileCommand(st Store, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	roomID := fs.Int("room", 0, "hanya periksa room dengan id ini (0 = semua room)")
+	apply := fs.Bool("apply", false, "perbaiki room_balance (default dry-run)")
+	withRows := fs.Bool("rows", false, "tampilkan baris ledger untuk room yang selisih")
+	all := fs.Bool("all", false, "tampilkan juga room yang saldonya sudah cocok")
+	asJSON := fs.Bool("json", false, "output dalam format JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	results, err := reconcileRooms(context.Background(), st, reconcileOptions{
+		RoomID:      *roomID,
+		Repair:      *apply,
+		WithRows:    *withRows || *asJSON,
+		IncludeSame: *all,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	mode := "DRY-RUN (tidak ada data yang diubah)"
+	if *apply {
+		mode = "APPLY"
+	}
+	fmt.Printf("🔎 Rekonsiliasi saldo room — %s\n", mode)
+
+	drifted := 0
+	for _, rr := range results {
+		status := "✅ cocok"
+		if rr.drifted() {
+			drifted++
+			status = "❌ selisih"
+			if rr.Repaired {
+				status = "🛠️ diperbaiki"
+			}
+		}
+		fmt.Printf("room %-6d tersimpan=%-14s seharusnya=%-14s selisih=%-12s %s\n",
+			rr.RoomID, rr.StoredSaldo.Fixed(), rr.ExpectedSaldo.Fixed(), rr.Selisih.Fixed(), status)
+		if rr.BalanceMissing {
+			fmt.Println("           (baris room_balance belum ada)")
+		}
+		for _, c := range rr.Contributions {
+			fmt.Printf("           %-17s #%-6d user=%-4d %-11s %14s  %s\n",
+				c.Source, c.ID, c.UserID, c.Jenis, c.Delta.Fixed(), c.OccurredAt.In(loc).Format("2006-01-02 15:04"))
+		}
+	}
+	fmt.Printf("Total room selisih: %d\n", drifted)
+	return nil
+}
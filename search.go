@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ==========================
+// 🔹 Pencarian transaksi
+// ==========================
+//
+// GET /transaksi/cari?q=listrik okt&limit=20&offset=0 mencari di keterangan,
+// kategori dan tag transaksi room. Setiap kata di q harus cocok dengan awal
+// sebuah kata ("okt" cocok dengan "Oktober"). Di PostgreSQL pencarian
+// memakai full-text search dengan konfigurasi wallet_search (stemmer
+// bahasa Indonesia, migrasi 0015) sehingga "bayar" juga cocok dengan
+// "pembayaran"; memoryStore hanya mencocokkan awal kata.
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchTerms     = 10
+)
+
+// searchTerms memecah q menjadi kata (huruf/angka saja, huruf kecil, tanpa
+// duplikat).
+func searchTerms(q string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(q), notWordRune) {
+		if !seen[w] && len(terms) < maxSearchTerms {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// tsQuery menyusun argumen to_tsquery: semua term harus cocok, masing-masing
+// sebagai awalan. Term hanya berisi huruf/angka sehingga aman disisipkan.
+func tsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// wordMatches memeriksa apakah salah satu kata di text diawali term.
+func wordMatches(text, term string) bool {
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), notWordRune) {
+		if strings.HasPrefix(w, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// markWords meng-escape text dan membungkus kata yang diawali salah satu
+// term dengan <mark>…</mark>, seperti ts_headline di PostgreSQL. Hasilnya
+// kosong jika tidak ada kata yang cocok.
+func markWords(text string, terms []string) string {
+	var b strings.Builder
+	marked := false
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && !notWordRune(runes[j]) {
+			j++
+		}
+		if j == i {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		word := string(runes[i:j])
+		hit := false
+		for _, t := range terms {
+			if strings.HasPrefix(strings.ToLower(word), t) {
+				hit = true
+				break
+			}
+		}
+		if hit {
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+			marked = true
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		i = j
+	}
+	if !marked {
+		return ""
+	}
+	return b.String()
+}
+
+// ==========================
+// 🔹 API: Cari transaksi room
+// ==========================
+func (s *Server) CariTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	terms := searchTerms(q.Get("q"))
+	if len(terms) == 0 {
+		writeError(w, http.StatusBadRequest, "query_required", "q wajib diisi")
+		return
+	}
+	limit, offset := defaultSearchLimit, 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			http.Error(w, fmt.Sprintf("limit harus antara 1 dan %d", maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "offset tidak valid", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	caller := currentUser(r.Context())
+	hits, total, err := s.store.Transactions().Search(context.Background(), caller.RoomID, terms, limit, offset)
+	if err != nil {
+		http.Error(w, "Gagal mencari transaksi: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var nextOffset any
+	if offset+len(hits) < total {
+		nextOffset = offset + len(hits)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "success",
+		"query":       strings.Join(terms, " "),
+		"total":       total,
+		"total_data":  len(hits),
+		"next_offset": nextOffset,
+		"hasil":       hits,
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCariTransaksi(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", nil)
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 500000, "keterangan": "Gaji freelance"})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 25000, "keterangan": "Kopi susu <b>gula aren</b>", "tags": []string{"ngopi"}})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 40000, "keterangan": "Makan siang kantor"})
+	deleted := ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Hiburan", "nominal": 1000, "keterangan": "Kopi kaleng"})
+	ts.expect(http.StatusOK, "DELETE", "/transaksi", u.Token, map[string]interface{}{"id": deleted})
+
+	// Rumah tangga lain tidak ikut dicari.
+	other := ts.newRoomUser("Caca", nil)
+	ts.addTx(other, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 1, "keterangan": "Kopi"})
+
+	out := ts.expect(http.StatusOK, "GET", "/transaksi/cari?q=kop", u.Token, nil)
+	hits := out["hasil"].([]interface{})
+	if num(out["total"]) != 1 || len(hits) != 1 {
+		t.Fatalf("cari kop: %v", out)
+	}
+	highlight := hits[0].(map[string]interface{})["highlight"].(map[string]interface{})
+	if !strings.Contains(highlight["keterangan"].(string), "<mark>Kopi</mark> susu &lt;b&gt;") || strings.Contains(highlight["keterangan"].(string), "<b>") {
+		t.Fatalf("highlight: %v", highlight)
+	}
+
+	// 🔹 Semua kata harus cocok; kategori dan tag ikut dicari
+	if out := ts.expect(http.StatusOK, "GET", "/transaksi/cari?q=makanan+siang", u.Token, nil); num(out["total"]) != 1 {
+		t.Fatalf("cari makanan siang: %v", out)
+	}
+	if out := ts.expect(http.StatusOK, "GET", "/transaksi/cari?q=ngopi", u.Token, nil); num(out["total"]) != 1 {
+		t.Fatalf("cari tag: %v", out)
+	}
+
+	page := ts.expect(http.StatusOK, "GET", "/transaksi/cari?q=makanan&limit=1", u.Token, nil)
+	if num(page["total"]) != 2 || num(page["total_data"]) != 1 || num(page["next_offset"]) != 1 {
+		t.Fatalf("halaman 1: %v", page)
+	}
+	page = ts.expect(http.StatusOK, "GET", "/transaksi/cari?q=makanan&limit=1&offset=1", u.Token, nil)
+	if num(page["total_data"]) != 1 || page["next_offset"] != nil {
+		t.Fatalf("halaman 2: %v", page)
+	}
+
+	if rec := ts.request("GET", "/transaksi/cari?q=+", u.Token, nil); rec.Code != http.StatusBadRequest || errorCode(t, rec) != "query_required" {
+		t.Fatalf("q kosong: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 🔹 Laporan bulanan room (XLSX / PDF)
+// ==========================
+//
+// GET /rooms/laporan?bulan=2026-03&format=xlsx|pdf berisi saldo awal,
+// seluruh transaksi bulan itu per hari dengan saldo berjalan, total per
+// kategori dan total per anggota room. Total bulan dan total per anggota
+// dihitung lewat summarize (sama seperti getUserSummary), jadi angkanya
+// cocok dengan yang tampil di aplikasi. Bulan mengikuti zona waktu room.
+// File dibuat dengan library Go murni (excelize, fpdf), tanpa layanan luar.
+
+var namaBulan = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// statementLine adalah satu transaksi di laporan.
+type statementLine struct {
+	Transaction
+	UserName string
+	Saldo    Money // saldo room setelah transaksi ini
+}
+
+type statementDay struct {
+	Date        time.Time
+	Lines       []statementLine
+	Pemasukan   Money
+	Pengeluaran Money
+}
+
+// statementTotal adalah total satu kategori atau satu anggota room.
+type statementTotal struct {
+	Name        string
+	Pemasukan   Money
+	Pengeluaran Money
+	Count       int
+}
+
+type monthlyStatement struct {
+	Room        Room
+	Month       time.Time // awal bulan di zona waktu room
+	Location    *time.Location
+	Opening     Money
+	Totals      LedgerTotals
+	Closing     Money
+	Days        []statementDay
+	Categories  []statementTotal
+	Partners    []statementTotal
+	GeneratedAt time.Time
+}
+
+func (st monthlyStatement) Period() string {
+	return fmt.Sprintf("%s %d", namaBulan[st.Month.Month()-1], st.Month.Year())
+}
+
+// buildStatement menyusun laporan bulan month (tahun & bulannya saja yang
+// dipakai) untuk room.
+func (s *Server) buildStatement(ctx context.Context, room Room, month time.Time) (monthlyStatement, error) {
+	tz := roomLocation(room)
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, tz)
+	st := monthlyStatement{
+		Room:        room,
+		Month:       start,
+		Location:    tz,
+		GeneratedAt: time.Now().In(tz),
+	}
+
+	// 🔹 Saldo awal = semua transaksi sebelum bulan ini
+	before, err := s.store.Transactions().Sum(ctx, LedgerFilter{RoomID: room.ID, To: start})
+	if err != nil {
+		return st, fmt.Errorf("Gagal menghitung saldo awal: %w", err)
+	}
+	st.Opening = before.Saldo()
+
+	members, err := s.store.Users().ListByRoom(ctx, room.ID)
+	if err != nil {
+		return st, fmt.Errorf("Gagal membaca anggota room: %w", err)
+	}
+	names := map[int]string{}
+	for _, m := range members {
+		names[m.ID] = m.FullName
+	}
+
+	// 🔹 Transaksi bulan ini per hari, dengan saldo berjalan
+	filter := LedgerFilter{RoomID: room.ID, From: start, To: start.AddDate(0, 1, 0), Ascending: true}
+	saldo := st.Opening
+	categories := map[string]*statementTotal{}
+	err = s.store.Transactions().Each(ctx, filter, func(t Transaction) error {
+		if _, ok := names[t.UserID]; !ok {
+			names[t.UserID] = fmt.Sprintf("User #%d", t.UserID) // sudah keluar dari room
+		}
+		saldo += t.Delta()
+		pemasukan, pengeluaran := legacyColumns(t)
+
+		day := t.OccurredAt.In(tz)
+		day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, tz)
+		if n := len(st.Days); n == 0 || !st.Days[n-1].Date.Equal(day) {
+			st.Days = append(st.Days, statementDay{Date: day})
+		}
+		d := &st.Days[len(st.Days)-1]
+		d.Lines = append(d.Lines, statementLine{Transaction: t, UserName: names[t.UserID], Saldo: saldo})
+		d.Pemasukan += pemasukan
+		d.Pengeluaran += pengeluaran
+
+		name := t.Kategori
+		if name == "" {
+			name = "Tanpa kategori"
+		}
+		c := categories[name]
+		if c == nil {
+			c = &statementTotal{Name: name}
+			categories[name] = c
+		}
+		c.Pemasukan += pemasukan
+		c.Pengeluaran += pengeluaran
+		c.Count++
+		return nil
+	})
+	if err != nil {
+		return st, fmt.Errorf("Gagal membaca transaksi: %w", err)
+	}
+
+	for _, c := range categories {
+		st.Categories = append(st.Categories, *c)
+	}
+	sort.Slice(st.Categories, func(i, j int) bool {
+		a, b := st.Categories[i], st.Categories[j]
+		if a.Pemasukan+a.Pengeluaran != b.Pemasukan+b.Pengeluaran {
+			return a.Pemasukan+a.Pengeluaran > b.Pemasukan+b.Pengeluaran
+		}
+		return a.Name < b.Name
+	})
+
+	// 🔹 Total bulan & per anggota memakai agregasi yang sama dengan
+	// getUserSummary: total room sekali, lalu satu Sum per anggota
+	st.Totals, err = s.store.Transactions().Sum(ctx, filter)
+	if err != nil {
+		return st, fmt.Errorf("Gagal menghitung total room: %w", err)
+	}
+	for id, name := range names {
+		userFilter := filter
+		userFilter.UserID = id
+		sum, err := s.store.Transactions().Sum(ctx, userFilter)
+		if err != nil {
+			return st, fmt.Errorf("Gagal menghitung total anggota: %w", err)
+		}
+		st.Partners = append(st.Partners, statementTotal{
+			Name:        name,
+			Pemasukan:   sum.Pemasukan,
+			Pengeluaran: sum.Pengeluaran,
+			Count:       sum.Count,
+		})
+	}
+	sort.Slice(st.Partners, func(i, j int) bool { return st.Partners[i].Name < st.Partners[j].Name })
+
+	st.Closing = st.Opening + st.Totals.Saldo()
+	return st, nil
+}
+
+// formatMoney menulis nominal gaya Indonesia: "IDR 1.234.567,50".
+func formatMoney(m Money, currency string) string {
+	fixed := m.Fixed()
+	sign := ""
+	if strings.HasPrefix(fixed, "-") {
+		sign, fixed = "-", fixed[1:]
+	}
+	units, frac, _ := strings.Cut(fixed, ".")
+	var b strings.Builder
+	for i, r := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return fmt.Sprintf("%s %s%s,%s", currency, sign, b.String(), frac)
+}
+
+// ==========================
+// 🔹 API: Unduh laporan bulanan
+// ==========================
+func (s *Server) LaporanBulanan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.Background()
+	caller := currentUser(r.Context())
+	room, err := s.store.Rooms().Get(ctx, caller.RoomID)
+	if err != nil {
+		http.Error(w, "Gagal membaca room: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q := r.URL.Query()
+	month := time.Now().In(roomLocation(room))
+	if v := q.Get("bulan"); v != "" {
+		if month, err = time.Parse("2006-01", v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_bulan", "bulan harus berformat YYYY-MM")
+			return
+		}
+	}
+
+	format := strings.ToLower(q.Get("format"))
+	var (
+		render      func(*bytes.Buffer, monthlyStatement) error
+		contentType string
+	)
+	switch format {
+	case "", "xlsx":
+		format, render = "xlsx", renderStatementXLSX
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case "pdf":
+		render, contentType = renderStatementPDF, "application/pdf"
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_format", "format harus xlsx atau pdf")
+		return
+	}
+
+	st, err := s.buildStatement(ctx, room, month)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := render(&buf, st); err != nil {
+		http.Error(w, "Gagal membuat laporan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("laporan-room-%d-%s.%s", room.ID, st.Month.Format("2006-01"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Content-Length", fmt.Sprint(buf.Len()))
+	w.Write(buf.Bytes())
+}
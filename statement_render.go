@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// ==========================
+// 🔹 Laporan bulanan: XLSX
+// ==========================
+
+// xlsxSheet menulis baris demi baris ke satu sheet. Nilai Money ditulis
+// sebagai angka dengan format ribuan supaya tetap bisa dijumlah di Excel.
+type xlsxSheet struct {
+	f      *excelize.File
+	name   string
+	row    int
+	styles [2][2]int // [bold][money]
+	err    error
+}
+
+func (x *xlsxSheet) add(bold bool, values ...interface{}) {
+	x.row++
+	for i, v := range values {
+		if x.err != nil {
+			return
+		}
+		cell, _ := excelize.CoordinatesToCellName(i+1, x.row)
+		isMoney := 0
+		if m, ok := v.(Money); ok {
+			v, isMoney = float64(m)/moneyScale, 1
+		}
+		if x.err = x.f.SetCellValue(x.name, cell, v); x.err != nil {
+			return
+		}
+		b := 0
+		if bold {
+			b = 1
+		}
+		if style := x.styles[b][isMoney]; style != 0 {
+			x.err = x.f.SetCellStyle(x.name, cell, cell, style)
+		}
+	}
+}
+
+func (x *xlsxSheet) skip() { x.row++ }
+
+func renderStatementXLSX(buf *bytes.Buffer, st monthlyStatement) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	numFmt := "#,##0.00"
+	var styles [2][2]int
+	for b := 0; b < 2; b++ {
+		for m := 0; m < 2; m++ {
+			if b == 0 && m == 0 {
+				continue
+			}
+			style := &excelize.Style{Font: &excelize.Font{Bold: b == 1}}
+			if m == 1 {
+				style.CustomNumFmt = &numFmt
+			}
+			id, err := f.NewStyle(style)
+			if err != nil {
+				return err
+			}
+			styles[b][m] = id
+		}
+	}
+
+	// 🔹 Sheet Ringkasan
+	if err := f.SetSheetName("Sheet1", "Ringkasan"); err != nil {
+		return err
+	}
+	sum := &xlsxSheet{f: f, name: "Ringkasan", styles: styles}
+	sum.add(true, "Laporan Bulanan "+st.Room.RoomName)
+	sum.add(false, "Periode", st.Period())
+	sum.add(false, "Mata uang", st.Room.Currency)
+	sum.add(false, "Zona waktu", st.Location.String())
+	sum.add(false, "Dibuat", st.GeneratedAt.Format("2006-01-02 15:04:05"))
+	sum.skip()
+	sum.add(false, "Saldo awal", st.Opening)
+	sum.add(false, "Total pemasukan", st.Totals.Pemasukan)
+	sum.add(false, "Total pengeluaran", st.Totals.Pengeluaran)
+	sum.add(true, "Saldo akhir", st.Closing)
+	sum.add(false, "Jumlah transaksi", st.Totals.Count)
+	sum.skip()
+	sum.add(true, "Kategori", "Pemasukan", "Pengeluaran", "Jumlah transaksi")
+	for _, c := range st.Categories {
+		sum.add(false, c.Name, c.Pemasukan, c.Pengeluaran, c.Count)
+	}
+	sum.skip()
+	sum.add(true, "Anggota", "Pemasukan", "Pengeluaran", "Jumlah transaksi")
+	for _, p := range st.Partners {
+		sum.add(false, p.Name, p.Pemasukan, p.Pengeluaran, p.Count)
+	}
+	if sum.err != nil {
+		return sum.err
+	}
+	if err := f.SetColWidth("Ringkasan", "A", "A", 24); err != nil {
+		return err
+	}
+	if err := f.SetColWidth("Ringkasan", "B", "D", 18); err != nil {
+		return err
+	}
+
+	// 🔹 Sheet Transaksi
+	if _, err := f.NewSheet("Transaksi"); err != nil {
+		return err
+	}
+	tx := &xlsxSheet{f: f, name: "Transaksi", styles: styles}
+	tx.add(true, "Tanggal", "Waktu", "Jenis", "Kategori", "Keterangan", "Tags", "Anggota", "Pemasukan", "Pengeluaran", "Saldo")
+	tx.add(false, st.Month.Format("2006-01-02"), "", "", "", "Saldo awal", "", "", "", "", st.Opening)
+	for _, d := range st.Days {
+		for _, l := range d.Lines {
+			pemasukan, pengeluaran := legacyColumns(l.Transaction)
+			tx.add(false, d.Date.Format("2006-01-02"), l.OccurredAt.In(st.Location).Format("15:04"),
+				l.Jenis, l.Kategori, l.Keterangan, strings.Join(l.Tags, ", "), l.UserName, pemasukan, pengeluaran, l.Saldo)
+		}
+		tx.add(true, d.Date.Format("2006-01-02"), "", "", "", "Total harian", "", "",
+			d.Pemasukan, d.Pengeluaran, d.Lines[len(d.Lines)-1].Saldo)
+	}
+	tx.add(true, "", "", "", "", "Saldo akhir", "", "", st.Totals.Pemasukan, st.Totals.Pengeluaran, st.Closing)
+	if tx.err != nil {
+		return tx.err
+	}
+	for col, width := range map[string]float64{"A": 12, "B": 8, "C": 13, "D": 16, "E": 36, "F": 20, "G": 18, "H": 16, "I": 16, "J": 16} {
+		if err := f.SetColWidth("Transaksi", col, col, width); err != nil {
+			return err
+		}
+	}
+	if err := f.SetPanes("Transaksi", &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	_, err := f.WriteTo(buf)
+	return err
+}
+
+// ==========================
+// 🔹 Laporan bulanan: PDF
+// ==========================
+
+func renderStatementPDF(buf *bytes.Buffer, st monthlyStatement) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // teks UTF-8 → cp1252 font bawaan
+	money := func(m Money) string { return formatMoney(m, st.Room.Currency) }
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s · %s · halaman %d/{nb}",
+			st.Room.RoomName, st.Period(), pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// 🔹 Judul & ringkasan
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.CellFormat(0, 8, tr("Laporan Bulanan "+st.Room.RoomName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, tr(fmt.Sprintf("Periode %s (%s) · dibuat %s", st.Period(), st.Location,
+		st.GeneratedAt.Format("2006-01-02 15:04"))), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	summaryRow := func(label string, m Money, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(50, 6, tr(label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 6, tr(money(m)), "", 1, "R", false, 0, "")
+	}
+	summaryRow("Saldo awal", st.Opening, false)
+	summaryRow("Total pemasukan", st.Totals.Pemasukan, false)
+	summaryRow("Total pengeluaran", st.Totals.Pengeluaran, false)
+	summaryRow("Saldo akhir", st.Closing, true)
+	pdf.Ln(4)
+
+	// 🔹 Tabel total (kategori / anggota)
+	totalsTable := func(title string, rows []statementTotal) {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(229, 231, 235)
+		pdf.CellFormat(70, 6, tr(title), "1", 0, "L", true, 0, "")
+		pdf.CellFormat(45, 6, "Pemasukan", "1", 0, "R", true, 0, "")
+		pdf.CellFormat(45, 6, "Pengeluaran", "1", 0, "R", true, 0, "")
+		pdf.CellFormat(30, 6, "Transaksi", "1", 1, "R", true, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, r := range rows {
+			pdf.CellFormat(70, 6, fitText(pdf, tr(r.Name), 68), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(45, 6, tr(money(r.Pemasukan)), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(45, 6, tr(money(r.Pengeluaran)), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(30, 6, fmt.Sprint(r.Count), "1", 1, "R", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+	totalsTable("Kategori", st.Categories)
+	totalsTable("Anggota", st.Partners)
+
+	// 🔹 Transaksi per hari dengan saldo berjalan
+	widths := []float64{12, 28, 51, 22, 25, 25, 27}
+	headers := []string{"Waktu", "Kategori", "Keterangan", "Anggota", "Pemasukan", "Pengeluaran", "Saldo"}
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(229, 231, 235)
+		for i, h := range headers {
+			align := "L"
+			if i >= 4 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, h, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, "Transaksi", "", 1, "L", false, 0, "")
+	header()
+	pdf.SetFont("Helvetica", "", 8)
+	for _, d := range st.Days {
+		if pdf.GetY() > 260 {
+			pdf.AddPage()
+			header()
+		}
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(243, 244, 246)
+		pdf.CellFormat(190, 6, tr(fmt.Sprintf("%d %s %d", d.Date.Day(), namaBulan[d.Date.Month()-1], d.Date.Year())),
+			"1", 1, "L", true, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		for _, l := range d.Lines {
+			pemasukan, pengeluaran := legacyColumns(l.Transaction)
+			cells := []string{
+				l.OccurredAt.In(st.Location).Format("15:04"),
+				fitText(pdf, tr(l.Kategori), widths[1]-2),
+				fitText(pdf, tr(l.Keterangan), widths[2]-2),
+				fitText(pdf, tr(l.UserName), widths[3]-2),
+				tr(moneyCell(pemasukan, money)),
+				tr(moneyCell(pengeluaran, money)),
+				tr(money(l.Saldo)),
+			}
+			for i, c := range cells {
+				align := "L"
+				if i >= 4 {
+					align = "R"
+				}
+				pdf.CellFormat(widths[i], 5.5, c, "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 5.5, "Total harian", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 5.5, tr(money(d.Pemasukan)), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 5.5, tr(money(d.Pengeluaran)), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[6], 5.5, tr(money(d.Lines[len(d.Lines)-1].Saldo)), "1", 1, "R", false, 0, "")
+	}
+	if len(st.Days) == 0 {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(190, 7, "Tidak ada transaksi di bulan ini", "1", 1, "C", false, 0, "")
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(buf)
+}
+
+// moneyCell mengosongkan kolom bernilai nol supaya tabel lebih mudah dibaca.
+func moneyCell(m Money, format func(Money) string) string {
+	if m == 0 {
+		return ""
+	}
+	return format(m)
+}
+
+// fitText memotong s (teks yang sudah diterjemahkan ke cp1252, satu byte
+// per karakter) supaya muat di lebar width mm.
+func fitText(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
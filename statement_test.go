@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestLaporanBulanan(t *testing.T) {
+	ts := newTestServer(t)
+	u := ts.newRoomUser("Andi", map[string]interface{}{"timezone": "Asia/Jakarta"})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 100000, "occurred_at": "2026-02-27 09:00"})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pengeluaran", "kategori": "Makanan", "nominal": 30000, "occurred_at": "2026-03-01 00:30", "keterangan": "Sarapan"})
+	ts.addTx(u, map[string]interface{}{"jenis": "Pemasukan", "kategori": "Lainnya", "nominal": 50000, "occurred_at": "2026-04-01 08:00"})
+
+	rec := ts.request("GET", "/rooms/laporan?bulan=2026-03", u.Token, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Disposition"), "laporan-room-") {
+		t.Fatalf("laporan xlsx: %d %v", rec.Code, rec.Header())
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("xlsx tidak bisa dibuka: %v", err)
+	}
+	defer f.Close()
+	if sheets := f.GetSheetList(); strings.Join(sheets, ",") != "Ringkasan,Transaksi" {
+		t.Fatalf("sheet: %v", sheets)
+	}
+	rows, err := f.GetRows("Transaksi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found int
+	for _, row := range rows {
+		for _, cell := range row {
+			if cell == "Sarapan" {
+				found++
+			}
+		}
+	}
+	if found != 1 {
+		t.Fatalf("transaksi Maret di sheet Transaksi: %v", rows)
+	}
+
+	rec = ts.request("GET", "/rooms/laporan?bulan=2026-03&format=pdf", u.Token, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF")) {
+		t.Fatalf("laporan pdf: %d %v", rec.Code, rec.Header())
+	}
+
+	for q, code := range map[string]string{"bulan=03-2026": "invalid_bulan", "format=docx": "invalid_format"} {
+		if rec := ts.request("GET", "/rooms/laporan?"+q, u.Token, nil); rec.Code != http.StatusBadRequest || errorCode(t, rec) != code {
+			t.Fatalf("%s: %d %s", q, rec.Code, rec.Body.String())
+		}
+	}
+}
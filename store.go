@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ==========================
+// 🔹 Storage (repository)
+// ==========================
+//
+// Handler tidak pernah menulis SQL langsung; semua akses data lewat Store.
+// Ada dua implementasi dengan semantik yang sama:
+//
+//   - pgStore     (store_pg.go)     → PostgreSQL lewat pgxpool
+//   - memoryStore (store_memory.go) → in-memory, untuk test/offline
+//
+// Method Lock* mengunci baris sampai transaksi selesai (SELECT ... FOR
+// UPDATE di PostgreSQL), jadi hanya bermakna jika dipanggil di dalam
+// WithTx.
+
+// ErrNotFound dikembalikan repository jika baris yang dicari tidak ada.
+var ErrNotFound = errors.New("data tidak ditemukan")
+
+type Store interface {
+	Users() UserRepository
+	Rooms() RoomRepository
+	Tokens() TokenRepository
+	Transactions() TransactionRepository
+	Balances() BalanceRepository
+	Categories() CategoryRepository
+	Tags() TagRepository
+	Audit() AuditRepository
+	Idempotency() IdempotencyRepository
+
+	// WithTx menjalankan fn di dalam satu transaksi database. Store yang
+	// diberikan ke fn (beserta semua repository-nya) ikut transaksi tsb;
+	// jika fn mengembalikan error, semua perubahan dibatalkan.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, u *User) error
+	Get(ctx context.Context, id int) (User, error)
+	Lock(ctx context.Context, id int) (User, error)
+	// FindByIdentifier mencari user berdasarkan email atau nama lengkap
+	// (tidak case-sensitive).
+	FindByIdentifier(ctx context.Context, identifier string) (User, error)
+	// UpdatePasswordHash hanya mengganti hash jika hash lama masih sama.
+	UpdatePasswordHash(ctx context.Context, id int, oldHash, newHash string) error
+	SetRoom(ctx context.Context, userID, roomID int) error
+	ListByRoom(ctx context.Context, roomID int) ([]User, error)
+	CountByRoom(ctx context.Context, roomID int) (int, error)
+}
+
+type RoomRepository interface {
+	Create(ctx context.Context, room *Room) error
+	Get(ctx context.Context, id int) (Room, error)
+	Lock(ctx context.Context, id int) (Room, error)
+	// UpdateSettings menyimpan room_name, forbid_negative_balance dan timezone.
+	UpdateSettings(ctx context.Context, room Room) error
+	ListIDs(ctx context.Context) ([]int, error)
+
+	CreateInvite(ctx context.Context, inv RoomInvite) error
+	// LockValidInvite mengunci undangan yang belum dipakai dan belum
+	// kedaluwarsa pada waktu now.
+	LockValidInvite(ctx context.Context, code string, now time.Time) (RoomInvite, error)
+	MarkInviteUsed(ctx context.Context, code string, userID int, at time.Time) error
+}
+
+type TokenRepository interface {
+	Create(ctx context.Context, t RefreshToken) error
+	// Consume mencabut refresh token yang masih berlaku dan mengembalikan
+	// pemiliknya (token hanya bisa dipakai sekali).
+	Consume(ctx context.Context, tokenHash string, now time.Time) (userID int, err error)
+	Revoke(ctx context.Context, tokenHash string, userID int, now time.Time) error
+}
+
+// LedgerFilter membatasi listing transaksi. Field bernilai nol diabaikan.
+// Transaksi yang sudah dihapus (soft delete) tidak ikut, kecuali Deleted
+// bernilai true: listing berisi isi tempat sampah saja, terbaru dihapus dulu.
+type LedgerFilter struct {
+	RoomID      int
+	UserID      int
+	Jenis       string // "Pemasukan" / "Pengeluaran"
+	CategoryIDs []int  // salah satu dari kategori ini
+	Deleted     bool
+
+	From       time.Time // occurred_at >= From
+	To         time.Time // occurred_at < To
+	MinNominal Money     // nominal >= MinNominal
+	MaxNominal Money     // nominal <= MaxNominal
+	Query      string    // dicari di keterangan dan kategori (tidak case-sensitive)
+
+	// Tags membatasi ke transaksi yang punya salah satu tag (atau semuanya
+	// jika AllTags), tanpa membedakan huruf besar/kecil.
+	Tags    []string
+	AllTags bool
+
+	// Urutan listing: SortBy (occurred_at atau nominal) lalu id, menurun
+	// kecuali Ascending.
+	SortBy    LedgerSort
+	Ascending bool
+
+	// After dan Limit hanya dipakai List untuk pagination: ambil paling
+	// banyak Limit baris yang urutannya sesudah After.
+	After *LedgerCursor
+	Limit int
+}
+
+type LedgerSort string
+
+const (
+	SortByDate   LedgerSort = "date"
+	SortByAmount LedgerSort = "amount"
+)
+
+// LedgerCursor adalah posisi baris terakhir halaman sebelumnya. Hanya field
+// yang sesuai SortBy (OccurredAt atau Nominal) dan ID yang dipakai.
+type LedgerCursor struct {
+	OccurredAt time.Time
+	Nominal    Money
+	ID         int
+}
+
+// LedgerTotals adalah agregat pemasukan/pengeluaran sebuah ledger.
+type LedgerTotals struct {
+	Pemasukan   Money
+	Pengeluaran Money
+	LastUpdate  *time.Time // updated_at terbaru
+	Count       int
+}
+
+func (t LedgerTotals) Saldo() Money { return t.Pemasukan - t.Pengeluaran }
+
+// TransactionRepository hanya melihat transaksi yang belum dihapus, kecuali
+// method *Deleted dan filter Deleted.
+type TransactionRepository interface {
+	// Create mengisi OccurredAt dengan waktu sekarang jika masih kosong.
+	Create(ctx context.Context, t *Transaction) error
+	Get(ctx context.Context, id int) (Transaction, error)
+	Lock(ctx context.Context, id int) (Transaction, error)
+	GetDeleted(ctx context.Context, id int) (Transaction, error)
+	LockDeleted(ctx context.Context, id int) (Transaction, error)
+	// GetByLegacyUserID mencari transaksi dari id lama user_transactions.
+	GetByLegacyUserID(ctx context.Context, legacyID int) (Transaction, error)
+	// NextLegacyUserID mengambil nomor berikutnya dari urutan id lama
+	// user_transactions, untuk transaksi baru dari endpoint lama.
+	NextLegacyUserID(ctx context.Context) (int, error)
+	// Update, SoftDelete dan Restore menaikkan t.Version.
+	Update(ctx context.Context, t *Transaction) error
+	// SoftDelete memindahkan transaksi ke tempat sampah dan mengisi
+	// t.DeletedAt/t.DeletedBy.
+	SoftDelete(ctx context.Context, t *Transaction, deletedBy int) error
+	Restore(ctx context.Context, t *Transaction) error
+	// RenameCategory menyalin nama baru kategori ke semua transaksinya,
+	// termasuk yang ada di tempat sampah, sambil menaikkan version dan
+	// updated_at. before dan after adalah isi transaksi yang berubah
+	// sebelum dan sesudahnya, urut id, untuk log audit.
+	RenameCategory(ctx context.Context, categoryID int, name string) (before, after []Transaction, err error)
+	// Each memanggil fn untuk setiap transaksi yang cocok dengan f sesuai
+	// urutan List tanpa menampung seluruh hasil di memori. Error dari fn
+	// menghentikan iterasi dan dikembalikan apa adanya.
+	Each(ctx context.Context, f LedgerFilter, fn func(Transaction) error) error
+	// Search mencari transaksi room (belum dihapus) yang setiap term-nya
+	// cocok dengan awal kata di kategori, tag atau keterangan; paling relevan
+	// dulu. total adalah jumlah seluruh hasil tanpa limit/offset.
+	Search(ctx context.Context, roomID int, terms []string, limit, offset int) (hits []SearchHit, total int, err error)
+	// ExternalIDs mengembalikan id transaksi room (termasuk yang di tempat
+	// sampah) untuk setiap ids yang sudah tersimpan sebagai external_id.
+	ExternalIDs(ctx context.Context, roomID int, ids []string) (map[string]int, error)
+	// PurgeDeleted menghapus permanen transaksi yang dihapus sebelum before.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// List diurutkan berdasarkan f.SortBy (default occurred_at) lalu id,
+	// terbaru dulu kecuali f.Ascending; f.After dan f.Limit untuk paging.
+	List(ctx context.Context, f LedgerFilter) ([]Transaction, error)
+	Sum(ctx context.Context, f LedgerFilter) (LedgerTotals, error)
+}
+
+type BalanceRepository interface {
+	// Get dan Lock mengembalikan ErrNotFound jika room belum punya baris
+	// saldo; lockRoomBalance (ledger.go) yang membuatkan baris tsb.
+	Get(ctx context.Context, roomID int) (Money, error)
+	Lock(ctx context.Context, roomID int) (Money, error)
+	Create(ctx context.Context, roomID int, saldo Money) error
+	Set(ctx context.Context, roomID int, saldo Money) error
+}
+
+// TagRepository mengelola tag room dan pasangannya dengan transaksi.
+// Transaction.Tags sudah terisi saat transaksi dibaca lewat
+// TransactionRepository; Create dan Update tidak menyimpan tag.
+type TagRepository interface {
+	// SetForTransaction mengganti seluruh tag transaksi. Tag yang belum ada
+	// di room dibuat; yang sudah ada dipakai dengan ejaan lamanya. Hasilnya
+	// adalah nama tag tersimpan, urut seperti Transaction.Tags.
+	SetForTransaction(ctx context.Context, roomID, transactionID int, names []string) ([]string, error)
+	// Search mencari tag room yang diawali prefix, paling sering dipakai dulu.
+	Search(ctx context.Context, roomID int, prefix string, limit int) ([]TagUsage, error)
+	// Totals merekap transaksi yang cocok dengan f per tag, urut nama tag.
+	Totals(ctx context.Context, f LedgerFilter) ([]TagTotals, error)
+}
+
+type CategoryRepository interface {
+	Create(ctx context.Context, c *Category) error
+	Get(ctx context.Context, id int) (Category, error)
+	// FindByName mencari kategori room berdasarkan nama (tidak
+	// case-sensitive), termasuk yang sudah diarsipkan.
+	FindByName(ctx context.Context, roomID int, name string) (Category, error)
+	// List diurutkan berdasarkan nama; arsip hanya ikut jika includeArchived.
+	List(ctx context.Context, roomID int, includeArchived bool) ([]Category, error)
+	// Update menyimpan name, icon, color, parent_id dan archived_at.
+	Update(ctx context.Context, c Category) error
+}
+
+// AuditRepository hanya bisa menambah baris; log audit tidak pernah diubah
+// atau dihapus.
+type AuditRepository interface {
+	Append(ctx context.Context, e *AuditEntry) error
+	// ListByTransaction diurutkan dari yang terlama.
+	ListByTransaction(ctx context.Context, transactionID int) ([]AuditEntry, error)
+	// ListByRoom diurutkan dari yang terbaru; beforeID > 0 hanya mengambil
+	// entri dengan id lebih kecil (untuk halaman berikutnya).
+	ListByRoom(ctx context.Context, roomID int, beforeID int64, limit int) ([]AuditEntry, error)
+}
+
+// IdempotencyRepository menyimpan respon request POST per (user, key).
+// Record yang sudah lewat ExpiresAt dianggap tidak ada.
+type IdempotencyRepository interface {
+	// Reserve menyimpan rec sebagai "sedang diproses" jika key belum dipakai.
+	// Jika key sudah dipakai, record lama dikembalikan dengan reserved false.
+	Reserve(ctx context.Context, rec IdempotencyRecord) (existing IdempotencyRecord, reserved bool, err error)
+	// Complete menyimpan respon untuk key yang sudah di-Reserve.
+	Complete(ctx context.Context, rec IdempotencyRecord) error
+	// Release menghapus reservasi supaya key bisa dicoba lagi.
+	Release(ctx context.Context, userID int, key string) error
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+}
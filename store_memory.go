@@ -0,0 +1,1031 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// 🔹 Store: in-memory
+// ==========================
+//
+// Dipakai untuk test dan menjalankan server tanpa database. Semantiknya
+// dibuat sama dengan pgStore:
+//
+//   - WithTx bekerja pada salinan data dan baru menggantikan data asli jika
+//     fn sukses, jadi error di tengah jalan membatalkan semua perubahan.
+//   - Transaksi dijalankan satu per satu (mutex global). Ini lebih ketat
+//     dari FOR UPDATE di PostgreSQL, tapi hasilnya sama: dua transaksi
+//     tidak pernah membaca saldo room yang sama lalu saling menimpa.
+//
+// Di dalam fn, pakai hanya Store yang diberikan WithTx; memanggil store
+// luar dari dalam transaksi akan deadlock.
+
+type memoryData struct {
+	users    map[int]User
+	rooms    map[int]Room
+	invites  map[string]RoomInvite
+	tokens   map[string]RefreshToken
+	txs      map[int]Transaction
+	cats     map[int]Category
+	tags     map[int]memTag
+	balances map[int]Money
+	audit    []AuditEntry // append-only, urut id
+	idem     map[idemKey]IdempotencyRecord
+
+	nextUserID, nextRoomID, nextTxID, nextLegacyUserID, nextCategoryID, nextTagID int
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := *d
+	c.users = maps.Clone(d.users)
+	c.rooms = maps.Clone(d.rooms)
+	c.invites = maps.Clone(d.invites)
+	c.tokens = maps.Clone(d.tokens)
+	c.txs = maps.Clone(d.txs)
+	c.cats = maps.Clone(d.cats)
+	c.tags = maps.Clone(d.tags)
+	c.balances = maps.Clone(d.balances)
+	c.audit = slices.Clip(d.audit) // append di salinan tidak menimpa data asli
+	c.idem = maps.Clone(d.idem)
+	return &c
+}
+
+type memoryDB struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+type memoryStore struct {
+	db *memoryDB
+	tx *memoryData // nil = di luar transaksi
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{db: &memoryDB{data: &memoryData{
+		users:    map[int]User{},
+		rooms:    map[int]Room{},
+		invites:  map[string]RoomInvite{},
+		tokens:   map[string]RefreshToken{},
+		txs:      map[int]Transaction{},
+		cats:     map[int]Category{},
+		tags:     map[int]memTag{},
+		balances: map[int]Money{},
+		idem:     map[idemKey]IdempotencyRecord{},
+	}}}
+}
+
+func (s *memoryStore) Users() UserRepository               { return memUsers{s} }
+func (s *memoryStore) Rooms() RoomRepository               { return memRooms{s} }
+func (s *memoryStore) Tokens() TokenRepository             { return memTokens{s} }
+func (s *memoryStore) Transactions() TransactionRepository { return memTransactions{s} }
+func (s *memoryStore) Balances() BalanceRepository         { return memBalances{s} }
+func (s *memoryStore) Categories() CategoryRepository      { return memCategories{s} }
+func (s *memoryStore) Tags() TagRepository                 { return memTags{s} }
+func (s *memoryStore) Audit() AuditRepository              { return memAudit{s} }
+func (s *memoryStore) Idempotency() IdempotencyRepository  { return memIdempotency{s} }
+
+func (s *memoryStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	work := s.db.data.clone()
+	if err := fn(&memoryStore{db: s.db, tx: work}); err != nil {
+		return err
+	}
+	s.db.data = work
+	return nil
+}
+
+// do menjalankan fn terhadap data transaksi aktif, atau terhadap data
+// utama (dengan mutex) jika dipanggil di luar transaksi.
+func (s *memoryStore) do(fn func(d *memoryData) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return fn(s.db.data)
+}
+
+// ==========================
+// 🔹 Users
+// ==========================
+type memUsers struct{ s *memoryStore }
+
+func (r memUsers) Create(ctx context.Context, u *User) error {
+	return r.s.do(func(d *memoryData) error {
+		for _, other := range d.users {
+			if other.Email == u.Email {
+				return fmt.Errorf("email %s sudah terdaftar", u.Email)
+			}
+		}
+		d.nextUserID++
+		u.ID = d.nextUserID
+		u.CreatedAt = time.Now()
+		d.users[u.ID] = *u
+		return nil
+	})
+}
+
+func (r memUsers) Get(ctx context.Context, id int) (User, error) {
+	var u User
+	err := r.s.do(func(d *memoryData) error {
+		var ok bool
+		if u, ok = d.users[id]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return u, err
+}
+
+func (r memUsers) Lock(ctx context.Context, id int) (User, error) {
+	return r.Get(ctx, id)
+}
+
+func (r memUsers) FindByIdentifier(ctx context.Context, identifier string) (User, error) {
+	var found User
+	err := r.s.do(func(d *memoryData) error {
+		for _, u := range sortedUsers(d.users) {
+			if strings.EqualFold(u.Email, identifier) || strings.EqualFold(u.FullName, identifier) {
+				found = u
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return found, err
+}
+
+func (r memUsers) UpdatePasswordHash(ctx context.Context, id int, oldHash, newHash string) error {
+	return r.s.do(func(d *memoryData) error {
+		if u, ok := d.users[id]; ok && u.PasswordHash == oldHash {
+			u.PasswordHash = newHash
+			d.users[id] = u
+		}
+		return nil
+	})
+}
+
+func (r memUsers) SetRoom(ctx context.Context, userID, roomID int) error {
+	return r.s.do(func(d *memoryData) error {
+		if u, ok := d.users[userID]; ok {
+			u.RoomID = roomID
+			d.users[userID] = u
+		}
+		return nil
+	})
+}
+
+func (r memUsers) ListByRoom(ctx context.Context, roomID int) ([]User, error) {
+	var list []User
+	err := r.s.do(func(d *memoryData) error {
+		for _, u := range sortedUsers(d.users) {
+			if u.RoomID == roomID {
+				list = append(list, u)
+			}
+		}
+		return nil
+	})
+	return list, err
+}
+
+func (r memUsers) CountByRoom(ctx context.Context, roomID int) (int, error) {
+	list, err := r.ListByRoom(ctx, roomID)
+	return len(list), err
+}
+
+func sortedUsers(m map[int]User) []User {
+	list := make([]User, 0, len(m))
+	for _, u := range m {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// ==========================
+// 🔹 Rooms & undangan
+// ==========================
+type memRooms struct{ s *memoryStore }
+
+func (r memRooms) Create(ctx context.Context, room *Room) error {
+	return r.s.do(func(d *memoryData) error {
+		d.nextRoomID++
+		room.ID = d.nextRoomID
+		room.CreatedAt = time.Now()
+		d.rooms[room.ID] = *room
+		return nil
+	})
+}
+
+func (r memRooms) Get(ctx context.Context, id int) (Room, error) {
+	var room Room
+	err := r.s.do(func(d *memoryData) error {
+		var ok bool
+		if room, ok = d.rooms[id]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return room, err
+}
+
+func (r memRooms) Lock(ctx context.Context, id int) (Room, error) {
+	return r.Get(ctx, id)
+}
+
+func (r memRooms) UpdateSettings(ctx context.Context, room Room) error {
+	return r.s.do(func(d *memoryData) error {
+		old, ok := d.rooms[room.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		old.RoomName, old.ForbidNegativeBalance, old.Timezone = room.RoomName, room.ForbidNegativeBalance, room.Timezone
+		d.rooms[room.ID] = old
+		return nil
+	})
+}
+
+func (r memRooms) ListIDs(ctx context.Context) ([]int, error) {
+	var ids []int
+	err := r.s.do(func(d *memoryData) error {
+		for id := range d.rooms {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		return nil
+	})
+	return ids, err
+}
+
+func (r memRooms) CreateInvite(ctx context.Context, inv RoomInvite) error {
+	return r.s.do(func(d *memoryData) error {
+		if _, ok := d.invites[inv.Code]; ok {
+			return fmt.Errorf("kode undangan %s sudah dipakai", inv.Code)
+		}
+		inv.CreatedAt = time.Now()
+		d.invites[inv.Code] = inv
+		return nil
+	})
+}
+
+func (r memRooms) LockValidInvite(ctx context.Context, code string, now time.Time) (RoomInvite, error) {
+	var inv RoomInvite
+	err := r.s.do(func(d *memoryData) error {
+		var ok bool
+		inv, ok = d.invites[code]
+		if !ok || inv.UsedAt != nil || !inv.ExpiresAt.After(now) {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return inv, err
+}
+
+func (r memRooms) MarkInviteUsed(ctx context.Context, code string, userID int, at time.Time) error {
+	return r.s.do(func(d *memoryData) error {
+		if inv, ok := d.invites[code]; ok {
+			inv.UsedBy = userID
+			inv.UsedAt = &at
+			d.invites[code] = inv
+		}
+		return nil
+	})
+}
+
+// ==========================
+// 🔹 Refresh token
+// ==========================
+type memTokens struct{ s *memoryStore }
+
+func (r memTokens) Create(ctx context.Context, t RefreshToken) error {
+	return r.s.do(func(d *memoryData) error {
+		if _, ok := d.tokens[t.TokenHash]; ok {
+			return fmt.Errorf("refresh token duplikat")
+		}
+		d.tokens[t.TokenHash] = t
+		return nil
+	})
+}
+
+func (r memTokens) Consume(ctx context.Context, tokenHash string, now time.Time) (int, error) {
+	var userID int
+	err := r.s.do(func(d *memoryData) error {
+		t, ok := d.tokens[tokenHash]
+		if !ok || t.RevokedAt != nil || !t.ExpiresAt.After(now) {
+			return ErrNotFound
+		}
+		t.RevokedAt = &now
+		d.tokens[tokenHash] = t
+		userID = t.UserID
+		return nil
+	})
+	return userID, err
+}
+
+func (r memTokens) Revoke(ctx context.Context, tokenHash string, userID int, now time.Time) error {
+	return r.s.do(func(d *memoryData) error {
+		if t, ok := d.tokens[tokenHash]; ok && t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			d.tokens[tokenHash] = t
+		}
+		return nil
+	})
+}
+
+// ==========================
+// 🔹 Transaksi
+// ==========================
+type memTransactions struct{ s *memoryStore }
+
+func (f LedgerFilter) matches(t Transaction) bool {
+	return (f.RoomID == 0 || f.RoomID == t.RoomID) &&
+		(f.UserID == 0 || f.UserID == t.UserID) &&
+		(f.Jenis == "" || f.Jenis == t.Jenis) &&
+		(len(f.CategoryIDs) == 0 || slices.Contains(f.CategoryIDs, t.CategoryID)) &&
+		(f.From.IsZero() || !t.OccurredAt.Before(f.From)) &&
+		(f.To.IsZero() || t.OccurredAt.Before(f.To)) &&
+		(f.MinNominal == 0 || t.Nominal >= f.MinNominal) &&
+		(f.MaxNominal == 0 || t.Nominal <= f.MaxNominal) &&
+		(f.Query == "" || containsFold(t.Keterangan, f.Query) || containsFold(t.Kategori, f.Query)) &&
+		f.Deleted == (t.DeletedAt != nil) &&
+		f.matchesTags(t.Tags)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (f LedgerFilter) matchesTags(tags []string) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+	has := map[string]bool{}
+	for _, name := range tags {
+		has[strings.ToLower(name)] = true
+	}
+	for _, want := range f.Tags {
+		found := has[strings.ToLower(want)]
+		if found && !f.AllTags {
+			return true
+		}
+		if !found && f.AllTags {
+			return false
+		}
+	}
+	return f.AllTags
+}
+
+// ledgerLess mengikuti ORDER BY di pgStore (ledgerOrder).
+func ledgerLess(f LedgerFilter, a, b Transaction) bool {
+	if f.Deleted {
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.ID > b.ID
+	}
+	if f.SortBy == SortByAmount {
+		if a.Nominal != b.Nominal {
+			return (a.Nominal < b.Nominal) == f.Ascending
+		}
+	} else if !a.OccurredAt.Equal(b.OccurredAt) {
+		return a.OccurredAt.Before(b.OccurredAt) == f.Ascending
+	}
+	return a.ID != b.ID && (a.ID < b.ID) == f.Ascending
+}
+
+func (r memTransactions) Create(ctx context.Context, t *Transaction) error {
+	return r.s.do(func(d *memoryData) error {
+		if t.LegacyUserID != nil {
+			for _, other := range d.txs {
+				if other.LegacyUserID != nil && *other.LegacyUserID == *t.LegacyUserID {
+					return fmt.Errorf("legacy_user_id %d sudah dipakai", *t.LegacyUserID)
+				}
+			}
+		}
+		if t.ExternalID != "" {
+			for _, other := range d.txs {
+				if other.RoomID == t.RoomID && other.ExternalID == t.ExternalID {
+					return fmt.Errorf("external_id %s sudah dipakai di room ini", t.ExternalID)
+				}
+			}
+		}
+		d.nextTxID++
+		t.ID = d.nextTxID
+		t.Tags = []string{} // tag disimpan lewat Tags().SetForTransaction
+		t.CreatedAt = time.Now()
+		t.UpdatedAt = t.CreatedAt
+		if t.OccurredAt.IsZero() {
+			t.OccurredAt = t.CreatedAt
+		}
+		t.Version = 1
+		d.txs[t.ID] = *t
+		return nil
+	})
+}
+
+func (r memTransactions) get(id int, deleted bool) (Transaction, error) {
+	var t Transaction
+	err := r.s.do(func(d *memoryData) error {
+		var ok bool
+		if t, ok = d.txs[id]; !ok || (t.DeletedAt != nil) != deleted {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return t, err
+}
+
+func (r memTransactions) Get(ctx context.Context, id int) (Transaction, error) {
+	return r.get(id, false)
+}
+
+func (r memTransactions) Lock(ctx context.Context, id int) (Transaction, error) {
+	return r.get(id, false)
+}
+
+func (r memTransactions) GetDeleted(ctx context.Context, id int) (Transaction, error) {
+	return r.get(id, true)
+}
+
+func (r memTransactions) LockDeleted(ctx context.Context, id int) (Transaction, error) {
+	return r.get(id, true)
+}
+
+func (r memTransactions) GetByLegacyUserID(ctx context.Context, legacyID int) (Transaction, error) {
+	var found Transaction
+	err := r.s.do(func(d *memoryData) error {
+		for _, t := range d.txs {
+			if t.LegacyUserID != nil && *t.LegacyUserID == legacyID && t.DeletedAt == nil {
+				found = t
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return found, err
+}
+
+func (r memTransactions) NextLegacyUserID(ctx context.Context) (int, error) {
+	var id int
+	err := r.s.do(func(d *memoryData) error {
+		d.nextLegacyUserID++
+		id = d.nextLegacyUserID
+		return nil
+	})
+	return id, err
+}
+
+func (r memTransactions) Update(ctx context.Context, t *Transaction) error {
+	return r.s.do(func(d *memoryData) error {
+		old, ok := d.txs[t.ID]
+		if !ok || old.DeletedAt != nil {
+			return ErrNotFound
+		}
+		old.Jenis, old.Kategori, old.CategoryID, old.Nominal, old.Keterangan = t.Jenis, t.Kategori, t.CategoryID, t.Nominal, t.Keterangan
+		old.OccurredAt = t.OccurredAt
+		old.UpdatedAt = time.Now()
+		old.Version++
+		d.txs[t.ID] = old
+		t.UpdatedAt, t.Version = old.UpdatedAt, old.Version
+		return nil
+	})
+}
+
+func (r memTransactions) SoftDelete(ctx context.Context, t *Transaction, deletedBy int) error {
+	return r.s.do(func(d *memoryData) error {
+		old, ok := d.txs[t.ID]
+		if !ok || old.DeletedAt != nil {
+			return ErrNotFound
+		}
+		now := time.Now()
+		old.DeletedAt, old.DeletedBy = &now, deletedBy
+		old.Version++
+		d.txs[t.ID] = old
+		t.DeletedAt, t.DeletedBy, t.Version = old.DeletedAt, deletedBy, old.Version
+		return nil
+	})
+}
+
+func (r memTransactions) Restore(ctx context.Context, t *Transaction) error {
+	return r.s.do(func(d *memoryData) error {
+		old, ok := d.txs[t.ID]
+		if !ok || old.DeletedAt == nil {
+			return ErrNotFound
+		}
+		old.DeletedAt, old.DeletedBy = nil, 0
+		old.Version++
+		d.txs[t.ID] = old
+		t.DeletedAt, t.DeletedBy, t.Version = nil, 0, old.Version
+		return nil
+	})
+}
+
+func (r memTransactions) RenameCategory(ctx context.Context, categoryID int, name string) (before, after []Transaction, err error) {
+	err = r.s.do(func(d *memoryData) error {
+		for _, t := range d.txs {
+			if t.CategoryID == categoryID && t.Kategori != name {
+				before = append(before, t)
+			}
+		}
+		sort.Slice(before, func(i, j int) bool { return before[i].ID < before[j].ID })
+		now := time.Now()
+		for _, t := range before {
+			t.Kategori = name
+			t.UpdatedAt = now
+			t.Version++
+			d.txs[t.ID] = t
+			after = append(after, t)
+		}
+		return nil
+	})
+	return before, after, err
+}
+
+// ExternalIDs juga melihat transaksi di tempat sampah, sama seperti unique
+// index external_id di Postgres.
+func (r memTransactions) ExternalIDs(ctx context.Context, roomID int, ids []string) (map[string]int, error) {
+	found := map[string]int{}
+	err := r.s.do(func(d *memoryData) error {
+		for _, t := range d.txs {
+			if t.RoomID == roomID && t.ExternalID != "" && slices.Contains(ids, t.ExternalID) {
+				found[t.ExternalID] = t.ID
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Search mengikuti bobot ts_rank di pgStore: kategori dan tag (A) lebih
+// relevan dari keterangan (B).
+func (r memTransactions) Search(ctx context.Context, roomID int, terms []string, limit, offset int) ([]SearchHit, int, error) {
+	var hits []SearchHit
+	err := r.s.do(func(d *memoryData) error {
+		for _, t := range d.txs {
+			if t.RoomID != roomID || t.DeletedAt != nil {
+				continue
+			}
+			hit := SearchHit{Transaction: t}
+			for _, term := range terms {
+				weight := float32(0)
+				if wordMatches(t.Keterangan, term) {
+					weight = 0.4
+				}
+				if wordMatches(t.Kategori, term) {
+					weight = 1
+				}
+				for _, tag := range t.Tags {
+					if wordMatches(tag, term) {
+						weight = 1
+					}
+				}
+				if weight == 0 {
+					hit.Rank = 0
+					break
+				}
+				hit.Rank += weight / float32(len(terms))
+			}
+			if hit.Rank == 0 {
+				continue
+			}
+			hit.Highlight.Keterangan = markWords(t.Keterangan, terms)
+			hit.Highlight.Kategori = markWords(t.Kategori, terms)
+			for _, tag := range t.Tags {
+				if markWords(tag, terms) != "" {
+					hit.Highlight.Tags = append(hit.Highlight.Tags, tag)
+				}
+			}
+			hits = append(hits, hit)
+		}
+		return nil
+	})
+
+	f := LedgerFilter{SortBy: SortByDate}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return ledgerLess(f, hits[i].Transaction, hits[j].Transaction)
+	})
+	total := len(hits)
+	hits = hits[min(offset, total):min(offset+limit, total)]
+	return hits, total, err
+}
+
+func (r memTransactions) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := r.s.do(func(d *memoryData) error {
+		for id, t := range d.txs {
+			if t.DeletedAt != nil && t.DeletedAt.Before(before) {
+				delete(d.txs, id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r memTransactions) List(ctx context.Context, f LedgerFilter) ([]Transaction, error) {
+	var list []Transaction
+	err := r.s.do(func(d *memoryData) error {
+		for _, t := range d.txs {
+			if f.matches(t) {
+				list = append(list, t)
+			}
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return ledgerLess(f, list[i], list[j]) })
+
+	// 🔹 Pagination seperti ledgerPage di pgStore
+	if f.After != nil && !f.Deleted {
+		cursor := Transaction{ID: f.After.ID, OccurredAt: f.After.OccurredAt, Nominal: f.After.Nominal}
+		i := 0
+		for i < len(list) && !ledgerLess(f, cursor, list[i]) {
+			i++
+		}
+		list = list[i:]
+	}
+	if f.Limit > 0 && len(list) > f.Limit {
+		list = list[:f.Limit]
+	}
+	return list, err
+}
+
+func (r memTransactions) Each(ctx context.Context, f LedgerFilter, fn func(Transaction) error) error {
+	list, err := r.List(ctx, f)
+	if err != nil {
+		return err
+	}
+	for _, t := range list {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r memTransactions) Sum(ctx context.Context, f LedgerFilter) (LedgerTotals, error) {
+	f.After, f.Limit = nil, 0
+	list, err := r.List(ctx, f)
+	var totals LedgerTotals
+	for _, t := range list {
+		if t.Jenis == "Pengeluaran" {
+			totals.Pengeluaran += t.Nominal
+		} else {
+			totals.Pemasukan += t.Nominal
+		}
+		totals.LastUpdate = latest(totals.LastUpdate, t.UpdatedAt)
+		totals.Count++
+	}
+	return totals, err
+}
+
+func latest(cur *time.Time, t time.Time) *time.Time {
+	if cur == nil || t.After(*cur) {
+		return &t
+	}
+	return cur
+}
+
+// ==========================
+// 🔹 Saldo room
+// ==========================
+type memBalances struct{ s *memoryStore }
+
+func (r memBalances) Get(ctx context.Context, roomID int) (Money, error) {
+	var saldo Money
+	err := r.s.do(func(d *memoryData) error {
+		var ok bool
+		if saldo, ok = d.balances[roomID]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return saldo, err
+}
+
+func (r memBalances) Lock(ctx context.Context, roomID int) (Money, error) {
+	return r.Get(ctx, roomID)
+}
+
+func (r memBalances) Create(ctx context.Context, roomID int, saldo Money) error {
+	return r.s.do(func(d *memoryData) error {
+		if _, ok := d.balances[roomID]; ok {
+			return fmt.Errorf("saldo room %d sudah ada", roomID)
+		}
+		d.balances[roomID] = saldo
+		return nil
+	})
+}
+
+func (r memBalances) Set(ctx context.Context, roomID int, saldo Money) error {
+	return r.s.do(func(d *memoryData) error {
+		if _, ok := d.balances[roomID]; ok {
+			d.balances[roomID] = saldo
+		}
+		return nil
+	})
+}
+
+// ==========================
+// 🔹 Kategori
+// ==========================
+type memCategories struct{ s *memoryStore }
+
+func (r memCategories) Create(ctx context.Context, c *Category) error {
+	return r.s.do(func(d *memoryData) error {
+		for _, other := range d.cats {
+			if other.RoomID == c.RoomID && strings.EqualFold(other.Name, c.Name) {
+				return fmt.Errorf("kategori %s sudah ada", c.Name)
+			}
+		}
+		d.nextCategoryID++
+		c.ID = d.nextCategoryID
+		c.CreatedAt = time.Now()
+		d.cats[c.ID] = *c
+		return nil
+	})
+}
+
+func (r memCategories) Get(ctx context.Context, id int) (Category, error) {
+	var c Category
+	err := r.s.do(func(d *memoryData) error {
+		var ok bool
+		if c, ok = d.cats[id]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return c, err
+}
+
+func (r memCategories) FindByName(ctx context.Context, roomID int, name string) (Category, error) {
+	var found Category
+	err := r.s.do(func(d *memoryData) error {
+		for _, c := range d.cats {
+			if c.RoomID == roomID && strings.EqualFold(c.Name, name) {
+				found = c
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return found, err
+}
+
+func (r memCategories) List(ctx context.Context, roomID int, includeArchived bool) ([]Category, error) {
+	var list []Category
+	err := r.s.do(func(d *memoryData) error {
+		for _, c := range d.cats {
+			if c.RoomID == roomID && (includeArchived || c.ArchivedAt == nil) {
+				list = append(list, c)
+			}
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool {
+		a, b := strings.ToLower(list[i].Name), strings.ToLower(list[j].Name)
+		if a != b {
+			return a < b
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, err
+}
+
+func (r memCategories) Update(ctx context.Context, c Category) error {
+	return r.s.do(func(d *memoryData) error {
+		old, ok := d.cats[c.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		for _, other := range d.cats {
+			if other.ID != c.ID && other.RoomID == old.RoomID && strings.EqualFold(other.Name, c.Name) {
+				return fmt.Errorf("kategori %s sudah ada", c.Name)
+			}
+		}
+		old.Name, old.Icon, old.Color, old.ParentID, old.ArchivedAt = c.Name, c.Icon, c.Color, c.ParentID, c.ArchivedAt
+		d.cats[c.ID] = old
+		return nil
+	})
+}
+
+// ==========================
+// 🔹 Tag
+// ==========================
+type memTags struct{ s *memoryStore }
+
+type memTag struct {
+	RoomID int
+	Name   string
+}
+
+func (r memTags) SetForTransaction(ctx context.Context, roomID, transactionID int, names []string) ([]string, error) {
+	var saved []string
+	err := r.s.do(func(d *memoryData) error {
+		t, ok := d.txs[transactionID]
+		if !ok {
+			return ErrNotFound
+		}
+		saved = []string{}
+		for _, name := range names {
+			found := ""
+			for _, tag := range d.tags {
+				if tag.RoomID == roomID && strings.EqualFold(tag.Name, name) {
+					found = tag.Name
+					break
+				}
+			}
+			if found == "" {
+				d.nextTagID++
+				d.tags[d.nextTagID] = memTag{RoomID: roomID, Name: name}
+				found = name
+			}
+			saved = append(saved, found)
+		}
+		sortTagNames(saved)
+		t.Tags = slices.Clone(saved)
+		d.txs[transactionID] = t
+		return nil
+	})
+	return saved, err
+}
+
+func (r memTags) Search(ctx context.Context, roomID int, prefix string, limit int) ([]TagUsage, error) {
+	list := []TagUsage{}
+	err := r.s.do(func(d *memoryData) error {
+		count := map[string]int{}
+		for _, t := range d.txs {
+			if t.RoomID == roomID && t.DeletedAt == nil {
+				for _, name := range t.Tags {
+					count[strings.ToLower(name)]++
+				}
+			}
+		}
+		for _, tag := range d.tags {
+			if tag.RoomID == roomID && strings.HasPrefix(strings.ToLower(tag.Name), strings.ToLower(prefix)) {
+				list = append(list, TagUsage{Name: tag.Name, Count: count[strings.ToLower(tag.Name)]})
+			}
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, err
+}
+
+func (r memTags) Totals(ctx context.Context, f LedgerFilter) ([]TagTotals, error) {
+	f.After, f.Limit = nil, 0
+	txs, err := memTransactions{r.s}.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	byTag := map[string]*TagTotals{}
+	for _, t := range txs {
+		for _, name := range t.Tags {
+			tt, ok := byTag[name]
+			if !ok {
+				tt = &TagTotals{Tag: name}
+				byTag[name] = tt
+			}
+			if t.Jenis == "Pengeluaran" {
+				tt.Pengeluaran += t.Nominal
+			} else {
+				tt.Pemasukan += t.Nominal
+			}
+			tt.Count++
+		}
+	}
+
+	names := slices.Collect(maps.Keys(byTag))
+	sortTagNames(names)
+	list := make([]TagTotals, 0, len(names))
+	for _, name := range names {
+		list = append(list, *byTag[name])
+	}
+	return list, nil
+}
+
+// ==========================
+// 🔹 Log audit
+// ==========================
+type memAudit struct{ s *memoryStore }
+
+func (r memAudit) Append(ctx context.Context, e *AuditEntry) error {
+	return r.s.do(func(d *memoryData) error {
+		e.ID = int64(len(d.audit) + 1)
+		e.CreatedAt = time.Now()
+		d.audit = append(d.audit, *e)
+		return nil
+	})
+}
+
+func (r memAudit) ListByTransaction(ctx context.Context, transactionID int) ([]AuditEntry, error) {
+	var list []AuditEntry
+	err := r.s.do(func(d *memoryData) error {
+		for _, e := range d.audit {
+			if e.TransactionID == transactionID {
+				list = append(list, e)
+			}
+		}
+		return nil
+	})
+	return list, err
+}
+
+func (r memAudit) ListByRoom(ctx context.Context, roomID int, beforeID int64, limit int) ([]AuditEntry, error) {
+	var list []AuditEntry
+	err := r.s.do(func(d *memoryData) error {
+		for i := len(d.audit) - 1; i >= 0 && len(list) < limit; i-- {
+			e := d.audit[i]
+			if e.RoomID == roomID && (beforeID == 0 || e.ID < beforeID) {
+				list = append(list, e)
+			}
+		}
+		return nil
+	})
+	return list, err
+}
+
+// ==========================
+// 🔹 Idempotency key
+// ==========================
+type memIdempotency struct{ s *memoryStore }
+
+type idemKey struct {
+	userID int
+	key    string
+}
+
+func (r memIdempotency) Reserve(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	var existing IdempotencyRecord
+	reserved := false
+	err := r.s.do(func(d *memoryData) error {
+		k := idemKey{rec.UserID, rec.Key}
+		if old, ok := d.idem[k]; ok && old.ExpiresAt.After(rec.CreatedAt) {
+			existing = old
+			return nil
+		}
+		rec.StatusCode, rec.Headers, rec.Body = 0, nil, nil
+		d.idem[k] = rec
+		reserved = true
+		return nil
+	})
+	return existing, reserved, err
+}
+
+func (r memIdempotency) Complete(ctx context.Context, rec IdempotencyRecord) error {
+	return r.s.do(func(d *memoryData) error {
+		k := idemKey{rec.UserID, rec.Key}
+		old, ok := d.idem[k]
+		if !ok {
+			return ErrNotFound
+		}
+		old.StatusCode, old.Headers, old.Body = rec.StatusCode, maps.Clone(rec.Headers), slices.Clone(rec.Body)
+		d.idem[k] = old
+		return nil
+	})
+}
+
+func (r memIdempotency) Release(ctx context.Context, userID int, key string) error {
+	return r.s.do(func(d *memoryData) error {
+		delete(d.idem, idemKey{userID, key})
+		return nil
+	})
+}
+
+func (r memIdempotency) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	err := r.s.do(func(d *memoryData) error {
+		for k, rec := range d.idem {
+			if !rec.ExpiresAt.After(now) {
+				delete(d.idem, k)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
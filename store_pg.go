@@ -0,0 +1,930 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ==========================
+// 🔹 Store: PostgreSQL (pgx)
+// ==========================
+
+// pgQuerier dipenuhi oleh *pgxpool.Pool maupun pgx.Tx, sehingga repository
+// yang sama bisa dipakai di dalam atau di luar transaksi.
+type pgQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type pgStore struct {
+	pool *pgxpool.Pool
+	q    pgQuerier
+	inTx bool
+}
+
+func newPgStore(pool *pgxpool.Pool) *pgStore {
+	return &pgStore{pool: pool, q: pool}
+}
+
+func (s *pgStore) Users() UserRepository               { return pgUsers{s.q} }
+func (s *pgStore) Rooms() RoomRepository               { return pgRooms{s.q} }
+func (s *pgStore) Tokens() TokenRepository             { return pgTokens{s.q} }
+func (s *pgStore) Transactions() TransactionRepository { return pgTransactions{s.q} }
+func (s *pgStore) Balances() BalanceRepository         { return pgBalances{s.q} }
+func (s *pgStore) Categories() CategoryRepository      { return pgCategories{s.q} }
+func (s *pgStore) Tags() TagRepository                 { return pgTags{s.q} }
+func (s *pgStore) Audit() AuditRepository              { return pgAudit{s.q} }
+func (s *pgStore) Idempotency() IdempotencyRepository  { return pgIdempotency{s.q} }
+
+func (s *pgStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	// Sudah di dalam transaksi → ikut transaksi yang sama
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Gagal memulai transaksi DB: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&pgStore{pool: s.pool, q: tx, inTx: true}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Gagal commit transaksi: %w", err)
+	}
+	return nil
+}
+
+// pgErr menerjemahkan pgx.ErrNoRows menjadi ErrNotFound.
+func pgErr(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ==========================
+// 🔹 Users
+// ==========================
+type pgUsers struct{ q pgQuerier }
+
+const pgUserColumns = `id, full_name, email, password_hash, COALESCE(room_id, 0), created_at`
+
+func scanUser(row pgx.Row) (User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.FullName, &u.Email, &u.PasswordHash, &u.RoomID, &u.CreatedAt)
+	return u, pgErr(err)
+}
+
+func (r pgUsers) Create(ctx context.Context, u *User) error {
+	return r.q.QueryRow(ctx,
+		`INSERT INTO users (full_name, email, password_hash, room_id, created_at)
+		 VALUES ($1, $2, $3, NULLIF($4, 0), NOW())
+		 RETURNING id, created_at`,
+		u.FullName, u.Email, u.PasswordHash, u.RoomID).Scan(&u.ID, &u.CreatedAt)
+}
+
+func (r pgUsers) Get(ctx context.Context, id int) (User, error) {
+	return scanUser(r.q.QueryRow(ctx, `SELECT `+pgUserColumns+` FROM users WHERE id = $1`, id))
+}
+
+func (r pgUsers) Lock(ctx context.Context, id int) (User, error) {
+	return scanUser(r.q.QueryRow(ctx, `SELECT `+pgUserColumns+` FROM users WHERE id = $1 FOR UPDATE`, id))
+}
+
+func (r pgUsers) FindByIdentifier(ctx context.Context, identifier string) (User, error) {
+	return scanUser(r.q.QueryRow(ctx,
+		`SELECT `+pgUserColumns+` FROM users
+		 WHERE LOWER(email) = LOWER($1) OR LOWER(full_name) = LOWER($1)
+		 ORDER BY id LIMIT 1`, identifier))
+}
+
+func (r pgUsers) UpdatePasswordHash(ctx context.Context, id int, oldHash, newHash string) error {
+	_, err := r.q.Exec(ctx,
+		`UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3`,
+		newHash, id, oldHash)
+	return err
+}
+
+func (r pgUsers) SetRoom(ctx context.Context, userID, roomID int) error {
+	_, err := r.q.Exec(ctx, `UPDATE users SET room_id = NULLIF($1, 0) WHERE id = $2`, roomID, userID)
+	return err
+}
+
+func (r pgUsers) ListByRoom(ctx context.Context, roomID int) ([]User, error) {
+	rows, err := r.q.Query(ctx, `SELECT `+pgUserColumns+` FROM users WHERE room_id = $1 ORDER BY id`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+func (r pgUsers) CountByRoom(ctx context.Context, roomID int) (int, error) {
+	var count int
+	err := r.q.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE room_id = $1`, roomID).Scan(&count)
+	return count, err
+}
+
+// ==========================
+// 🔹 Rooms & undangan
+// ==========================
+type pgRooms struct{ q pgQuerier }
+
+const pgRoomColumns = `id, room_name, COALESCE(owner_id, 0), currency, forbid_negative_balance, timezone, created_at`
+
+func scanRoom(row pgx.Row) (Room, error) {
+	var rm Room
+	err := row.Scan(&rm.ID, &rm.RoomName, &rm.OwnerID, &rm.Currency, &rm.ForbidNegativeBalance, &rm.Timezone, &rm.CreatedAt)
+	return rm, pgErr(err)
+}
+
+func (r pgRooms) Create(ctx context.Context, room *Room) error {
+	return r.q.QueryRow(ctx,
+		`INSERT INTO rooms (room_name, owner_id, currency, forbid_negative_balance, timezone, created_at)
+		 VALUES ($1, NULLIF($2, 0), $3, $4, $5, NOW())
+		 RETURNING id, created_at`,
+		room.RoomName, room.OwnerID, room.Currency, room.ForbidNegativeBalance, room.Timezone).Scan(&room.ID, &room.CreatedAt)
+}
+
+func (r pgRooms) UpdateSettings(ctx context.Context, room Room) error {
+	tag, err := r.q.Exec(ctx,
+		`UPDATE rooms SET room_name = $1, forbid_negative_balance = $2, timezone = $3 WHERE id = $4`,
+		room.RoomName, room.ForbidNegativeBalance, room.Timezone, room.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r pgRooms) Get(ctx context.Context, id int) (Room, error) {
+	return scanRoom(r.q.QueryRow(ctx, `SELECT `+pgRoomColumns+` FROM rooms WHERE id = $1`, id))
+}
+
+func (r pgRooms) Lock(ctx context.Context, id int) (Room, error) {
+	return scanRoom(r.q.QueryRow(ctx, `SELECT `+pgRoomColumns+` FROM rooms WHERE id = $1 FOR UPDATE`, id))
+}
+
+func (r pgRooms) ListIDs(ctx context.Context) ([]int, error) {
+	rows, err := r.q.Query(ctx, `SELECT id FROM rooms ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+func (r pgRooms) CreateInvite(ctx context.Context, inv RoomInvite) error {
+	_, err := r.q.Exec(ctx,
+		`INSERT INTO room_invites (code, room_id, created_by, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, NOW())`, inv.Code, inv.RoomID, inv.CreatedBy, inv.ExpiresAt)
+	return err
+}
+
+func (r pgRooms) LockValidInvite(ctx context.Context, code string, now time.Time) (RoomInvite, error) {
+	var inv RoomInvite
+	err := r.q.QueryRow(ctx,
+		`SELECT code, room_id, created_by, expires_at, created_at FROM room_invites
+		 WHERE code = $1 AND used_at IS NULL AND expires_at > $2
+		 FOR UPDATE`, code, now).Scan(&inv.Code, &inv.RoomID, &inv.CreatedBy, &inv.ExpiresAt, &inv.CreatedAt)
+	return inv, pgErr(err)
+}
+
+func (r pgRooms) MarkInviteUsed(ctx context.Context, code string, userID int, at time.Time) error {
+	_, err := r.q.Exec(ctx,
+		`UPDATE room_invites SET used_by = $1, used_at = $2 WHERE code = $3`, userID, at, code)
+	return err
+}
+
+// ==========================
+// 🔹 Refresh token
+// ==========================
+type pgTokens struct{ q pgQuerier }
+
+func (r pgTokens) Create(ctx context.Context, t RefreshToken) error {
+	_, err := r.q.Exec(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4)`, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	return err
+}
+
+func (r pgTokens) Consume(ctx context.Context, tokenHash string, now time.Time) (int, error) {
+	var userID int
+	err := r.q.QueryRow(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $2
+		 WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > $2
+		 RETURNING user_id`, tokenHash, now).Scan(&userID)
+	return userID, pgErr(err)
+}
+
+func (r pgTokens) Revoke(ctx context.Context, tokenHash string, userID int, now time.Time) error {
+	_, err := r.q.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $3
+		 WHERE token_hash = $1 AND user_id = $2 AND revoked_at IS NULL`, tokenHash, userID, now)
+	return err
+}
+
+// ==========================
+// 🔹 Transaksi
+// ==========================
+type pgTransactions struct{ q pgQuerier }
+
+// ledgerWhere menyusun klausa WHERE dari filter.
+func ledgerWhere(f LedgerFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	if f.Deleted {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+
+	if f.RoomID != 0 {
+		args = append(args, f.RoomID)
+		conditions = append(conditions, fmt.Sprintf("room_id = $%d", len(args)))
+	}
+	if f.UserID != 0 {
+		args = append(args, f.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if f.Jenis != "" {
+		args = append(args, f.Jenis)
+		conditions = append(conditions, fmt.Sprintf("jenis = $%d", len(args)))
+	}
+	if len(f.CategoryIDs) > 0 {
+		args = append(args, f.CategoryIDs)
+		conditions = append(conditions, fmt.Sprintf("category_id = ANY($%d::int[])", len(args)))
+	}
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", len(args)))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		conditions = append(conditions, fmt.Sprintf("occurred_at < $%d", len(args)))
+	}
+	if f.MinNominal != 0 {
+		args = append(args, f.MinNominal)
+		conditions = append(conditions, fmt.Sprintf("nominal >= $%d", len(args)))
+	}
+	if f.MaxNominal != 0 {
+		args = append(args, f.MaxNominal)
+		conditions = append(conditions, fmt.Sprintf("nominal <= $%d", len(args)))
+	}
+	if f.Query != "" {
+		args = append(args, "%"+likeEscape(f.Query)+"%")
+		conditions = append(conditions, fmt.Sprintf("(LOWER(keterangan) LIKE $%d OR LOWER(kategori) LIKE $%d)", len(args), len(args)))
+	}
+	if len(f.Tags) > 0 {
+		lower := make([]string, len(f.Tags))
+		for i, name := range f.Tags {
+			lower[i] = strings.ToLower(name)
+		}
+		args = append(args, lower)
+		match := fmt.Sprintf(`SELECT tt.transaction_id FROM transaction_tags tt
+			JOIN tags tg ON tg.id = tt.tag_id
+			WHERE LOWER(tg.name) = ANY($%d::text[])`, len(args))
+		if f.AllTags {
+			match += fmt.Sprintf(" GROUP BY tt.transaction_id HAVING COUNT(DISTINCT LOWER(tg.name)) = cardinality($%d::text[])", len(args))
+		}
+		conditions = append(conditions, "id IN ("+match+")")
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ledgerSortColumn adalah kolom urutan utama listing (sebelum id).
+func ledgerSortColumn(f LedgerFilter) string {
+	if f.SortBy == SortByAmount {
+		return "nominal"
+	}
+	return "occurred_at"
+}
+
+func ledgerOrder(f LedgerFilter) string {
+	if f.Deleted {
+		return " ORDER BY deleted_at DESC, id DESC"
+	}
+	dir := "DESC"
+	if f.Ascending {
+		dir = "ASC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", ledgerSortColumn(f), dir, dir)
+}
+
+// ledgerPage menambahkan syarat cursor (f.After) ke klausa WHERE dan LIMIT
+// ke akhir query.
+func ledgerPage(f LedgerFilter, where string, args []interface{}) (string, string, []interface{}) {
+	if f.After != nil && !f.Deleted {
+		var key interface{} = f.After.OccurredAt
+		if f.SortBy == SortByAmount {
+			key = f.After.Nominal
+		}
+		op := "<"
+		if f.Ascending {
+			op = ">"
+		}
+		args = append(args, key, f.After.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", ledgerSortColumn(f), op, len(args)-1, len(args))
+	}
+	limit := ""
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		limit = fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return where, limit, args
+}
+
+const pgTxColumns = `id, user_id, room_id, jenis, kategori, COALESCE(category_id, 0), nominal, keterangan,
+	occurred_at, created_at, updated_at, legacy_user_id, version, deleted_at, COALESCE(deleted_by, 0),
+	COALESCE(external_id, ''),
+	ARRAY(SELECT tg.name FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id
+	      WHERE tt.transaction_id = transactions.id ORDER BY LOWER(tg.name), tg.name)`
+
+func scanTx(row pgx.Row) (Transaction, error) {
+	var t Transaction
+	err := row.Scan(&t.ID, &t.UserID, &t.RoomID, &t.Jenis, &t.Kategori, &t.CategoryID, &t.Nominal, &t.Keterangan,
+		&t.OccurredAt, &t.CreatedAt, &t.UpdatedAt, &t.LegacyUserID, &t.Version, &t.DeletedAt, &t.DeletedBy, &t.ExternalID, &t.Tags)
+	return t, pgErr(err)
+}
+
+func (r pgTransactions) Create(ctx context.Context, t *Transaction) error {
+	if t.OccurredAt.IsZero() {
+		t.OccurredAt = time.Now()
+	}
+	return r.q.QueryRow(ctx,
+		`INSERT INTO transactions (user_id, room_id, jenis, kategori, category_id, nominal, keterangan,
+		                           occurred_at, created_at, updated_at, legacy_user_id, external_id)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, $7, $8, NOW(), NOW(), $9, NULLIF($10, ''))
+		 RETURNING id, created_at, updated_at, version`,
+		t.UserID, t.RoomID, t.Jenis, t.Kategori, t.CategoryID, t.Nominal, t.Keterangan, t.OccurredAt, t.LegacyUserID,
+		t.ExternalID).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt, &t.Version)
+}
+
+func (r pgTransactions) Get(ctx context.Context, id int) (Transaction, error) {
+	return scanTx(r.q.QueryRow(ctx,
+		`SELECT `+pgTxColumns+` FROM transactions WHERE id = $1 AND deleted_at IS NULL`, id))
+}
+
+func (r pgTransactions) Lock(ctx context.Context, id int) (Transaction, error) {
+	return scanTx(r.q.QueryRow(ctx,
+		`SELECT `+pgTxColumns+` FROM transactions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id))
+}
+
+func (r pgTransactions) GetDeleted(ctx context.Context, id int) (Transaction, error) {
+	return scanTx(r.q.QueryRow(ctx,
+		`SELECT `+pgTxColumns+` FROM transactions WHERE id = $1 AND deleted_at IS NOT NULL`, id))
+}
+
+func (r pgTransactions) LockDeleted(ctx context.Context, id int) (Transaction, error) {
+	return scanTx(r.q.QueryRow(ctx,
+		`SELECT `+pgTxColumns+` FROM transactions WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`, id))
+}
+
+func (r pgTransactions) GetByLegacyUserID(ctx context.Context, legacyID int) (Transaction, error) {
+	return scanTx(r.q.QueryRow(ctx,
+		`SELECT `+pgTxColumns+` FROM transactions WHERE legacy_user_id = $1 AND deleted_at IS NULL`, legacyID))
+}
+
+func (r pgTransactions) NextLegacyUserID(ctx context.Context) (int, error) {
+	var id int
+	err := r.q.QueryRow(ctx, `SELECT nextval('transactions_legacy_user_id_seq')`).Scan(&id)
+	return id, err
+}
+
+func (r pgTransactions) Update(ctx context.Context, t *Transaction) error {
+	err := r.q.QueryRow(ctx,
+		`UPDATE transactions
+		 SET jenis = $1, kategori = $2, category_id = NULLIF($3, 0), nominal = $4, keterangan = $5, occurred_at = $6,
+		     updated_at = NOW(), version = version + 1
+		 WHERE id = $7 AND deleted_at IS NULL
+		 RETURNING updated_at, version`,
+		t.Jenis, t.Kategori, t.CategoryID, t.Nominal, t.Keterangan, t.OccurredAt, t.ID).Scan(&t.UpdatedAt, &t.Version)
+	return pgErr(err)
+}
+
+func (r pgTransactions) SoftDelete(ctx context.Context, t *Transaction, deletedBy int) error {
+	err := r.q.QueryRow(ctx,
+		`UPDATE transactions SET deleted_at = NOW(), deleted_by = NULLIF($1, 0), version = version + 1
+		 WHERE id = $2 AND deleted_at IS NULL
+		 RETURNING deleted_at, version`, deletedBy, t.ID).Scan(&t.DeletedAt, &t.Version)
+	if err != nil {
+		return pgErr(err)
+	}
+	t.DeletedBy = deletedBy
+	return nil
+}
+
+func (r pgTransactions) Restore(ctx context.Context, t *Transaction) error {
+	err := r.q.QueryRow(ctx,
+		`UPDATE transactions SET deleted_at = NULL, deleted_by = NULL, version = version + 1
+		 WHERE id = $1 AND deleted_at IS NOT NULL
+		 RETURNING version`, t.ID).Scan(&t.Version)
+	if err != nil {
+		return pgErr(err)
+	}
+	t.DeletedAt, t.DeletedBy = nil, 0
+	return nil
+}
+
+func (r pgTransactions) RenameCategory(ctx context.Context, categoryID int, name string) (before, after []Transaction, err error) {
+	rows, err := r.q.Query(ctx,
+		`WITH old AS (
+		     SELECT id AS old_id, kategori AS old_kategori, updated_at AS old_updated_at FROM transactions
+		     WHERE category_id = $2 AND kategori <> $1
+		     FOR UPDATE
+		 )
+		 UPDATE transactions SET kategori = $1, updated_at = NOW(), version = version + 1
+		 FROM old WHERE transactions.id = old.old_id
+		 RETURNING old_kategori, old_updated_at, `+pgTxColumns, name, categoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			t          Transaction
+			oldName    string
+			oldUpdated time.Time
+		)
+		err := rows.Scan(&oldName, &oldUpdated, &t.ID, &t.UserID, &t.RoomID, &t.Jenis, &t.Kategori, &t.CategoryID, &t.Nominal, &t.Keterangan,
+			&t.OccurredAt, &t.CreatedAt, &t.UpdatedAt, &t.LegacyUserID, &t.Version, &t.DeletedAt, &t.DeletedBy, &t.ExternalID, &t.Tags)
+		if err != nil {
+			return nil, nil, err
+		}
+		old := t
+		old.Kategori, old.UpdatedAt, old.Version = oldName, oldUpdated, t.Version-1
+		before, after = append(before, old), append(after, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(before, func(i, j int) bool { return before[i].ID < before[j].ID })
+	sort.Slice(after, func(i, j int) bool { return after[i].ID < after[j].ID })
+	return before, after, nil
+}
+
+// searchHeadline menandai kata yang cocok; teks di-escape HTML dulu supaya
+// hasilnya aman ditampilkan.
+const searchHeadline = `ts_headline('wallet_search',
+	replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+	q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')`
+
+func (r pgTransactions) Search(ctx context.Context, roomID int, terms []string, limit, offset int) ([]SearchHit, int, error) {
+	query := tsQuery(terms)
+	var total int
+	err := r.q.QueryRow(ctx,
+		`SELECT COUNT(*) FROM transactions, to_tsquery('wallet_search', $2) q
+		 WHERE room_id = $1 AND deleted_at IS NULL AND search_vector @@ q`, roomID, query).Scan(&total)
+	if err != nil || total == 0 {
+		return []SearchHit{}, total, err
+	}
+
+	rows, err := r.q.Query(ctx, `
+		SELECT `+pgTxColumns+`,
+			ts_rank(search_vector, q) AS rank,
+			`+fmt.Sprintf(searchHeadline, "keterangan")+`,
+			`+fmt.Sprintf(searchHeadline, "kategori")+`,
+			ARRAY(SELECT tg.name FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id
+			      WHERE tt.transaction_id = transactions.id AND to_tsvector('wallet_search', tg.name) @@ q
+			      ORDER BY LOWER(tg.name), tg.name)
+		FROM transactions, to_tsquery('wallet_search', $2) q
+		WHERE room_id = $1 AND deleted_at IS NULL AND search_vector @@ q
+		ORDER BY rank DESC, occurred_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, roomID, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var h SearchHit
+		t := &h.Transaction
+		err := rows.Scan(&t.ID, &t.UserID, &t.RoomID, &t.Jenis, &t.Kategori, &t.CategoryID, &t.Nominal, &t.Keterangan,
+			&t.OccurredAt, &t.CreatedAt, &t.UpdatedAt, &t.LegacyUserID, &t.Version, &t.DeletedAt, &t.DeletedBy, &t.ExternalID, &t.Tags,
+			&h.Rank, &h.Highlight.Keterangan, &h.Highlight.Kategori, &h.Highlight.Tags)
+		if err != nil {
+			return nil, 0, err
+		}
+		// ts_headline mengembalikan seluruh teks walau tidak ada yang cocok
+		if !strings.Contains(h.Highlight.Keterangan, "<mark>") {
+			h.Highlight.Keterangan = ""
+		}
+		if !strings.Contains(h.Highlight.Kategori, "<mark>") {
+			h.Highlight.Kategori = ""
+		}
+		hits = append(hits, h)
+	}
+	return hits, total, rows.Err()
+}
+
+func (r pgTransactions) ExternalIDs(ctx context.Context, roomID int, ids []string) (map[string]int, error) {
+	found := map[string]int{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+	rows, err := r.q.Query(ctx,
+		`SELECT external_id, id FROM transactions WHERE room_id = $1 AND external_id = ANY($2::text[])`, roomID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			ext string
+			id  int
+		)
+		if err := rows.Scan(&ext, &id); err != nil {
+			return nil, err
+		}
+		found[ext] = id
+	}
+	return found, rows.Err()
+}
+
+func (r pgTransactions) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.q.Exec(ctx, `DELETE FROM transactions WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r pgTransactions) List(ctx context.Context, f LedgerFilter) ([]Transaction, error) {
+	var list []Transaction
+	err := r.Each(ctx, f, func(t Transaction) error {
+		list = append(list, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r pgTransactions) Each(ctx context.Context, f LedgerFilter, fn func(Transaction) error) error {
+	where, args := ledgerWhere(f)
+	where, limit, args := ledgerPage(f, where, args)
+	rows, err := r.q.Query(ctx, `SELECT `+pgTxColumns+` FROM transactions`+where+ledgerOrder(f)+limit, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTx(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r pgTransactions) Sum(ctx context.Context, f LedgerFilter) (LedgerTotals, error) {
+	where, args := ledgerWhere(f)
+	var t LedgerTotals
+	err := r.q.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(nominal) FILTER (WHERE jenis = 'Pemasukan'), 0)::bigint,
+			COALESCE(SUM(nominal) FILTER (WHERE jenis = 'Pengeluaran'), 0)::bigint,
+			MAX(updated_at),
+			COUNT(*)
+		FROM transactions`+where, args...).Scan(&t.Pemasukan, &t.Pengeluaran, &t.LastUpdate, &t.Count)
+	return t, err
+}
+
+// ==========================
+// 🔹 Saldo room
+// ==========================
+type pgBalances struct{ q pgQuerier }
+
+func (r pgBalances) Get(ctx context.Context, roomID int) (Money, error) {
+	var saldo Money
+	err := r.q.QueryRow(ctx, `SELECT total_saldo FROM room_balance WHERE room_id = $1`, roomID).Scan(&saldo)
+	return saldo, pgErr(err)
+}
+
+func (r pgBalances) Lock(ctx context.Context, roomID int) (Money, error) {
+	var saldo Money
+	err := r.q.QueryRow(ctx,
+		`SELECT total_saldo FROM room_balance WHERE room_id = $1 FOR UPDATE`, roomID).Scan(&saldo)
+	return saldo, pgErr(err)
+}
+
+func (r pgBalances) Create(ctx context.Context, roomID int, saldo Money) error {
+	_, err := r.q.Exec(ctx,
+		`INSERT INTO room_balance (room_id, total_saldo, tanggal_update) VALUES ($1, $2, NOW())`, roomID, saldo)
+	return err
+}
+
+func (r pgBalances) Set(ctx context.Context, roomID int, saldo Money) error {
+	_, err := r.q.Exec(ctx,
+		`UPDATE room_balance SET total_saldo = $1, tanggal_update = NOW() WHERE room_id = $2`, saldo, roomID)
+	return err
+}
+
+// ==========================
+// 🔹 Kategori
+// ==========================
+type pgCategories struct{ q pgQuerier }
+
+const pgCategoryColumns = `id, room_id, COALESCE(parent_id, 0), name, icon, color, archived_at, created_at`
+
+func scanCategory(row pgx.Row) (Category, error) {
+	var c Category
+	err := row.Scan(&c.ID, &c.RoomID, &c.ParentID, &c.Name, &c.Icon, &c.Color, &c.ArchivedAt, &c.CreatedAt)
+	return c, pgErr(err)
+}
+
+func (r pgCategories) Create(ctx context.Context, c *Category) error {
+	return r.q.QueryRow(ctx,
+		`INSERT INTO categories (room_id, parent_id, name, icon, color, archived_at, created_at)
+		 VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, NOW())
+		 RETURNING id, created_at`,
+		c.RoomID, c.ParentID, c.Name, c.Icon, c.Color, c.ArchivedAt).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r pgCategories) Get(ctx context.Context, id int) (Category, error) {
+	return scanCategory(r.q.QueryRow(ctx, `SELECT `+pgCategoryColumns+` FROM categories WHERE id = $1`, id))
+}
+
+func (r pgCategories) FindByName(ctx context.Context, roomID int, name string) (Category, error) {
+	return scanCategory(r.q.QueryRow(ctx,
+		`SELECT `+pgCategoryColumns+` FROM categories WHERE room_id = $1 AND LOWER(name) = LOWER($2)`, roomID, name))
+}
+
+func (r pgCategories) List(ctx context.Context, roomID int, includeArchived bool) ([]Category, error) {
+	rows, err := r.q.Query(ctx,
+		`SELECT `+pgCategoryColumns+` FROM categories
+		 WHERE room_id = $1 AND ($2 OR archived_at IS NULL)
+		 ORDER BY LOWER(name), id`, roomID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Category
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (r pgCategories) Update(ctx context.Context, c Category) error {
+	tag, err := r.q.Exec(ctx,
+		`UPDATE categories SET name = $1, icon = $2, color = $3, parent_id = NULLIF($4, 0), archived_at = $5
+		 WHERE id = $6`, c.Name, c.Icon, c.Color, c.ParentID, c.ArchivedAt, c.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ==========================
+// 🔹 Tag
+// ==========================
+type pgTags struct{ q pgQuerier }
+
+func (r pgTags) SetForTransaction(ctx context.Context, roomID, transactionID int, names []string) ([]string, error) {
+	if _, err := r.q.Exec(ctx, `DELETE FROM transaction_tags WHERE transaction_id = $1`, transactionID); err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return []string{}, nil
+	}
+
+	// Tag baru dibuat; ON CONFLICT ... DO UPDATE dipakai supaya RETURNING
+	// juga mengembalikan tag yang sudah ada (dengan ejaan lamanya).
+	rows, err := r.q.Query(ctx,
+		`INSERT INTO tags (room_id, name, created_at)
+		 SELECT $1, name, NOW() FROM UNNEST($2::text[]) AS name
+		 ON CONFLICT (room_id, LOWER(name)) DO UPDATE SET name = tags.name
+		 RETURNING id`, roomID, names)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = r.q.Query(ctx,
+		`WITH linked AS (
+		     INSERT INTO transaction_tags (transaction_id, tag_id)
+		     SELECT $1, UNNEST($2::int[])
+		     RETURNING tag_id
+		 )
+		 SELECT tg.name FROM linked JOIN tags tg ON tg.id = linked.tag_id
+		 ORDER BY LOWER(tg.name), tg.name`, transactionID, ids)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r pgTags) Search(ctx context.Context, roomID int, prefix string, limit int) ([]TagUsage, error) {
+	rows, err := r.q.Query(ctx,
+		`SELECT tg.name, COUNT(t.id)
+		 FROM tags tg
+		 LEFT JOIN transaction_tags tt ON tt.tag_id = tg.id
+		 LEFT JOIN transactions t ON t.id = tt.transaction_id AND t.deleted_at IS NULL
+		 WHERE tg.room_id = $1 AND LOWER(tg.name) LIKE $2 ESCAPE '\'
+		 GROUP BY tg.id, tg.name
+		 ORDER BY COUNT(t.id) DESC, LOWER(tg.name)
+		 LIMIT $3`, roomID, likePrefix(prefix), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []TagUsage{}
+	for rows.Next() {
+		var u TagUsage
+		if err := rows.Scan(&u.Name, &u.Count); err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+// likeEscape meng-escape karakter khusus LIKE dan mengecilkan huruf.
+func likeEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(strings.ToLower(s))
+}
+
+// likePrefix membuat pola LIKE "prefix%".
+func likePrefix(prefix string) string {
+	return likeEscape(prefix) + "%"
+}
+
+func (r pgTags) Totals(ctx context.Context, f LedgerFilter) ([]TagTotals, error) {
+	where, args := ledgerWhere(f)
+	rows, err := r.q.Query(ctx, `
+		SELECT
+			tg.name,
+			COALESCE(SUM(t.nominal) FILTER (WHERE t.jenis = 'Pemasukan'), 0)::bigint,
+			COALESCE(SUM(t.nominal) FILTER (WHERE t.jenis = 'Pengeluaran'), 0)::bigint,
+			COUNT(*)
+		FROM (SELECT id, jenis, nominal FROM transactions`+where+`) t
+		JOIN transaction_tags tt ON tt.transaction_id = t.id
+		JOIN tags tg ON tg.id = tt.tag_id
+		GROUP BY tg.id, tg.name
+		ORDER BY LOWER(tg.name), tg.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []TagTotals{}
+	for rows.Next() {
+		var tt TagTotals
+		if err := rows.Scan(&tt.Tag, &tt.Pemasukan, &tt.Pengeluaran, &tt.Count); err != nil {
+			return nil, err
+		}
+		list = append(list, tt)
+	}
+	return list, rows.Err()
+}
+
+// ==========================
+// 🔹 Log audit
+// ==========================
+type pgAudit struct{ q pgQuerier }
+
+const pgAuditColumns = `id, transaction_id, room_id, user_id, action, before, after, saldo_delta, created_at`
+
+func scanAudit(row pgx.Row) (AuditEntry, error) {
+	var e AuditEntry
+	err := row.Scan(&e.ID, &e.TransactionID, &e.RoomID, &e.UserID, &e.Action, &e.Before, &e.After, &e.SaldoDelta, &e.CreatedAt)
+	return e, pgErr(err)
+}
+
+func (r pgAudit) Append(ctx context.Context, e *AuditEntry) error {
+	return r.q.QueryRow(ctx,
+		`INSERT INTO transaction_audit (transaction_id, room_id, user_id, action, before, after, saldo_delta, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		 RETURNING id, created_at`,
+		e.TransactionID, e.RoomID, e.UserID, e.Action, e.Before, e.After, e.SaldoDelta).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r pgAudit) list(ctx context.Context, sql string, args ...any) ([]AuditEntry, error) {
+	rows, err := r.q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []AuditEntry
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (r pgAudit) ListByTransaction(ctx context.Context, transactionID int) ([]AuditEntry, error) {
+	return r.list(ctx,
+		`SELECT `+pgAuditColumns+` FROM transaction_audit WHERE transaction_id = $1 ORDER BY id`, transactionID)
+}
+
+func (r pgAudit) ListByRoom(ctx context.Context, roomID int, beforeID int64, limit int) ([]AuditEntry, error) {
+	return r.list(ctx,
+		`SELECT `+pgAuditColumns+` FROM transaction_audit
+		 WHERE room_id = $1 AND ($2::bigint = 0 OR id < $2::bigint)
+		 ORDER BY id DESC LIMIT $3`, roomID, beforeID, limit)
+}
+
+// ==========================
+// 🔹 Idempotency key
+// ==========================
+type pgIdempotency struct{ q pgQuerier }
+
+func (r pgIdempotency) Reserve(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	// Baris yang sudah kedaluwarsa boleh ditimpa; baris yang masih berlaku
+	// membuat INSERT tidak mengembalikan apa-apa.
+	err := r.q.QueryRow(ctx,
+		`INSERT INTO idempotency_keys (user_id, key, fingerprint, status_code, created_at, expires_at)
+		 VALUES ($1, $2, $3, 0, $4, $5)
+		 ON CONFLICT (user_id, key) DO UPDATE
+		 SET fingerprint = EXCLUDED.fingerprint, status_code = 0, headers = NULL, body = NULL,
+		     created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		 WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		 RETURNING user_id`,
+		rec.UserID, rec.Key, rec.Fingerprint, rec.CreatedAt, rec.ExpiresAt).Scan(&rec.UserID)
+	if err == nil {
+		return IdempotencyRecord{}, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return IdempotencyRecord{}, false, err
+	}
+
+	var existing IdempotencyRecord
+	err = r.q.QueryRow(ctx,
+		`SELECT user_id, key, fingerprint, status_code, COALESCE(headers, '{}'::jsonb), COALESCE(body, ''::bytea), created_at, expires_at
+		 FROM idempotency_keys WHERE user_id = $1 AND key = $2`, rec.UserID, rec.Key).
+		Scan(&existing.UserID, &existing.Key, &existing.Fingerprint, &existing.StatusCode,
+			&existing.Headers, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+	return existing, false, pgErr(err)
+}
+
+func (r pgIdempotency) Complete(ctx context.Context, rec IdempotencyRecord) error {
+	tag, err := r.q.Exec(ctx,
+		`UPDATE idempotency_keys SET status_code = $3, headers = $4, body = $5
+		 WHERE user_id = $1 AND key = $2`,
+		rec.UserID, rec.Key, rec.StatusCode, rec.Headers, rec.Body)
+	if err == nil && tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r pgIdempotency) Release(ctx context.Context, userID int, key string) error {
+	_, err := r.q.Exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	return err
+}
+
+func (r pgIdempotency) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.q.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
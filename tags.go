@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ==========================
+// 🔹 Tag transaksi
+// ==========================
+//
+// Selain satu kategori, transaksi boleh punya beberapa tag bebas
+// ("honeymoon", "reimbursable", "kantor"). Tag milik room dan dicocokkan
+// tanpa membedakan huruf besar/kecil; ejaan yang pertama dipakai yang
+// disimpan. Listing bisa difilter dengan ?tags=a,b&tag_mode=any|all.
+
+const (
+	maxTagsPerTransaction = 20
+	maxTagLen             = 30
+	defaultTagLimit       = 10
+	maxTagLimit           = 50
+)
+
+// normalizeTags merapikan daftar tag dari request: spasi dibuang, tag
+// kosong dan duplikat (tidak case-sensitive) dihapus.
+func normalizeTags(tags []string) ([]string, string) {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLen {
+			return nil, fmt.Sprintf("tag maksimal %d karakter", maxTagLen)
+		}
+		if strings.Contains(tag, ",") {
+			return nil, "tag tidak boleh mengandung koma"
+		}
+		seen[strings.ToLower(tag)] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxTagsPerTransaction {
+		return nil, fmt.Sprintf("maksimal %d tag per transaksi", maxTagsPerTransaction)
+	}
+	return out, ""
+}
+
+// sortTagNames mengurutkan tag seperti Transaction.Tags.
+func sortTagNames(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		a, b := strings.ToLower(names[i]), strings.ToLower(names[j])
+		if a != b {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
+}
+
+// setTransactionTags menyimpan tag t.Tags di dalam transaksi DB tx dan
+// mengganti t.Tags dengan ejaan yang tersimpan.
+func setTransactionTags(ctx context.Context, tx Store, t *Transaction) error {
+	saved, err := tx.Tags().SetForTransaction(ctx, t.RoomID, t.ID, t.Tags)
+	if err != nil {
+		return fmt.Errorf("Gagal menyimpan tag: %w", err)
+	}
+	t.Tags = saved
+	return nil
+}
+
+// readTagFilter mengisi f.Tags/f.AllTags dari query ?tags=a,b&tag_mode=any|all.
+func readTagFilter(r *http.Request, f *LedgerFilter) string {
+	q := r.URL.Query()
+	if v := q.Get("tags"); v != "" {
+		tags, msg := normalizeTags(strings.Split(v, ","))
+		if msg != "" {
+			return msg
+		}
+		f.Tags = tags
+	}
+	switch q.Get("tag_mode") {
+	case "", "any":
+	case "all":
+		f.AllTags = true
+	default:
+		return "tag_mode harus any atau all"
+	}
+	return ""
+}
+
+// ==========================
+// 🔹 API: Autocomplete tag room
+// ==========================
+//
+// GET /tag?q=ho&limit=10, tag yang paling sering dipakai dulu.
+func (s *Server) GetTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := defaultTagLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxTagLimit {
+			http.Error(w, fmt.Sprintf("limit harus antara 1 dan %d", maxTagLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	caller := currentUser(r.Context())
+	tags, err := s.store.Tags().Search(context.Background(), caller.RoomID, strings.TrimSpace(q.Get("q")), limit)
+	if err != nil {
+		http.Error(w, "Gagal mengambil tag: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "success",
+		"total_data": len(tags),
+		"tag":        tags,
+	})
+}
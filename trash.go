@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ==========================
+// 🔹 Tempat sampah transaksi
+// ==========================
+//
+// Menghapus transaksi hanya mengisi deleted_at/deleted_by dan membalik
+// efeknya ke room_balance. Selama masa retensi (TRASH_RETENTION) transaksi
+// masih bisa dipulihkan; setelah itu job purge menghapusnya permanen.
+
+// ==========================
+// 🔹 API: Daftar transaksi di tempat sampah room
+// ==========================
+func (s *Server) GetSampahTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Hanya GET method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	caller := currentUser(r.Context())
+	list, err := s.store.Transactions().List(context.Background(), LedgerFilter{RoomID: caller.RoomID, Deleted: true})
+	if err != nil {
+		http.Error(w, "Gagal mengambil data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type TrashItem struct {
+		Transaction
+		PurgeAt time.Time `json:"purge_at"`
+	}
+	items := make([]TrashItem, 0, len(list))
+	for _, t := range list {
+		items = append(items, TrashItem{Transaction: t, PurgeAt: t.DeletedAt.Add(cfg.TrashRetention.Duration)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "success",
+		"total_data": len(items),
+		"transaksi":  items,
+	})
+}
+
+// ==========================
+// 🔹 API: Pulihkan transaksi dari tempat sampah
+// ==========================
+func (s *Server) PulihkanTransaksi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Hanya POST method yang diizinkan", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON tidak valid", http.StatusBadRequest)
+		return
+	}
+	if req.ID == 0 {
+		http.Error(w, "id wajib diisi", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	var (
+		t                          Transaction
+		perubahanSaldo, totalSaldo Money
+	)
+	err := s.store.WithTx(ctx, func(tx Store) error {
+		// 🔹 Ambil (dan kunci) transaksi di tempat sampah
+		var err error
+		t, err = tx.Transactions().LockDeleted(ctx, req.ID)
+		if err != nil {
+			return clientError(http.StatusNotFound, "", "Transaksi tidak ada di tempat sampah: "+err.Error())
+		}
+
+		before := t
+		if err := tx.Transactions().Restore(ctx, &t); err != nil {
+			return fmt.Errorf("Gagal memulihkan transaksi: %w", err)
+		}
+
+		// 🔹 Terapkan kembali efek transaksi ke saldo room
+		perubahanSaldo = t.Delta()
+		totalSaldo, err = addRoomBalance(ctx, tx, t.RoomID, perubahanSaldo)
+		if err != nil {
+			return fmt.Errorf("Gagal memperbarui total saldo: %w", err)
+		}
+		if err := guardNegativeBalance(ctx, tx, t.RoomID, totalSaldo); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, currentUser(r.Context()).ID, auditRestore, &before, &t, perubahanSaldo)
+	})
+	if err != nil {
+		writeTxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":          "success",
+		"message":         "Transaksi berhasil dipulihkan",
+		"transaksi":       t,
+		"perubahan_saldo": perubahanSaldo,
+		"total_saldo":     totalSaldo,
+	})
+}
+
+// ==========================
+// 🔹 Job: hapus permanen isi tempat sampah
+// ==========================
+
+// purgeTrash menghapus permanen transaksi yang sudah lebih lama dari
+// retention di tempat sampah. Saldo tidak berubah karena efeknya sudah
+// dibalik saat transaksi dihapus.
+func purgeTrash(ctx context.Context, st Store, retention time.Duration) (int64, error) {
+	return st.Transactions().PurgeDeleted(ctx, time.Now().Add(-retention))
+}
+
+// startTrashPurger menjalankan purgeTrash di background setiap interval
+// sampai ctx selesai.
+func startTrashPurger(ctx context.Context, st Store, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			n, err := purgeTrash(ctx, st, retention)
+			if err != nil {
+				log.Printf("⚠️ Gagal membersihkan tempat sampah: %v\n", err)
+			} else if n > 0 {
+				log.Printf("🗑️  %d transaksi dihapus permanen dari tempat sampah\n", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}